@@ -0,0 +1,141 @@
+package amount
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Rounding defines how the fractional part of base units is dropped
+type Rounding string
+
+const (
+	// RoundDown truncates towards zero, the payout never exceeds the exact value
+	RoundDown Rounding = "down"
+	// RoundUp rounds away from zero
+	RoundUp Rounding = "up"
+	// RoundHalfUp rounds to the nearest, halves away from zero
+	RoundHalfUp Rounding = "half_up"
+	// RoundHalfEven rounds to the nearest, halves to the even base unit
+	RoundHalfEven Rounding = "half_even"
+)
+
+func (r Rounding) Valid() bool {
+	switch r {
+	case RoundDown, RoundUp, RoundHalfUp, RoundHalfEven:
+		return true
+	}
+	return false
+}
+
+// UnmarshalText rejects unknown rounding modes, so they fail on loading the config
+func (r *Rounding) UnmarshalText(text []byte) error {
+	rounding := Rounding(text)
+	if !rounding.Valid() {
+		return errors.Errorf("unknown rounding mode: %s", text)
+	}
+	*r = rounding
+	return nil
+}
+
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// ParseDecimal parses the decimal string, e.g. "-1.25", into the exact value
+func ParseDecimal(raw string) (*big.Rat, error) {
+	if !decimalPattern.MatchString(raw) {
+		return nil, errors.Errorf("invalid decimal: %q", raw)
+	}
+	value, ok := new(big.Rat).SetString(raw)
+	if !ok {
+		return nil, errors.Errorf("invalid decimal: %q", raw)
+	}
+	return value, nil
+}
+
+// Parse converts the decimal amount of whole tokens to base units of the token with the decimals,
+// returns the dropped fraction of the base unit as dust
+func Parse(raw string, decimals int, mode Rounding) (*big.Int, *big.Rat, error) {
+	value, err := ParseDecimal(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, dust := Round(Shift(value, decimals), mode)
+	return base, dust, nil
+}
+
+// Format renders base units of the token with the decimals as the exact decimal amount of whole tokens
+func Format(base *big.Int, decimals int) string {
+	return FormatDecimal(Shift(new(big.Rat).SetInt(base), -decimals))
+}
+
+// maxScale bounds the digits of values without a finite decimal expansion, e.g. 1/3
+const maxScale = 96
+
+// FormatDecimal renders the value as the shortest exact decimal, the ones without a finite expansion are rounded to maxScale digits
+func FormatDecimal(value *big.Rat) string {
+	scale := 0
+	for scaled := new(big.Rat).Set(value); !scaled.IsInt() && scale < maxScale; scale++ {
+		scaled.Mul(scaled, big.NewRat(10, 1))
+	}
+	result := value.FloatString(scale)
+	if scale > 0 {
+		result = strings.TrimRight(strings.TrimRight(result, "0"), ".")
+	}
+	return result
+}
+
+// Convert applies the rate to base units shifted by 10^-shift and rounds the result,
+// returns the dropped fraction of the resulting base unit as dust
+func Convert(base *big.Int, shift int, rate *big.Rat, mode Rounding) (*big.Int, *big.Rat) {
+	value := Shift(new(big.Rat).SetInt(base), -shift)
+	return Round(value.Mul(value, rate), mode)
+}
+
+// FromDec converts the fixed point decimal of the chain to the exact value
+func FromDec(dec sdk.Dec) *big.Rat {
+	return new(big.Rat).SetFrac(dec.BigInt(), pow10(sdk.Precision))
+}
+
+// Shift multiplies the value by 10^exp
+func Shift(value *big.Rat, exp int) *big.Rat {
+	result := new(big.Rat).Set(value)
+	if exp >= 0 {
+		return result.Mul(result, new(big.Rat).SetInt(pow10(exp)))
+	}
+	return result.Quo(result, new(big.Rat).SetInt(pow10(-exp)))
+}
+
+// Round rounds the value to the integer by the mode, the dust is the value less the result
+func Round(value *big.Rat, mode Rounding) (*big.Int, *big.Rat) {
+	// quotient is truncated towards zero, the remainder has the sign of the value
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+
+	if remainder.Sign() != 0 {
+		// doubled remainder compared with the denominator tells whether the fraction is below, at or above the half
+		half := new(big.Int).Abs(remainder)
+		half.Lsh(half, 1)
+		cmp := half.Cmp(value.Denom())
+
+		away := false
+		switch mode {
+		case RoundUp:
+			away = true
+		case RoundHalfUp:
+			away = cmp >= 0
+		case RoundHalfEven:
+			away = cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1)
+		}
+		if away {
+			quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+		}
+	}
+
+	dust := new(big.Rat).SetInt(quotient)
+	return quotient, dust.Sub(value, dust)
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
@@ -0,0 +1,167 @@
+package amount
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+var modes = []Rounding{RoundDown, RoundUp, RoundHalfUp, RoundHalfEven}
+
+// base is an arbitrary amount of base units up to 2^200, so it does not fit into int64
+type base struct {
+	*big.Int
+}
+
+func (base) Generate(rand *rand.Rand, _ int) reflect.Value {
+	value := new(big.Int).Rand(rand, new(big.Int).Lsh(big.NewInt(1), uint(rand.Intn(200)+1)))
+	if rand.Intn(4) == 0 {
+		value.Neg(value)
+	}
+	return reflect.ValueOf(base{value})
+}
+
+// decimals of the token up to 36, so the precision of the chain is exceeded
+type decimals int
+
+func (decimals) Generate(rand *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(decimals(rand.Intn(37)))
+}
+
+// rate is an arbitrary fixed point decimal of the chain
+type rate struct {
+	sdk.Dec
+}
+
+func (rate) Generate(rand *rand.Rand, _ int) reflect.Value {
+	value := new(big.Int).Rand(rand, new(big.Int).Lsh(big.NewInt(1), uint(rand.Intn(100)+1)))
+	return reflect.ValueOf(rate{sdk.NewDecFromBigIntWithPrec(value, sdk.Precision)})
+}
+
+func check(t *testing.T, property interface{}) {
+	t.Helper()
+	if err := quick.Check(property, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	check(t, func(amount base, d decimals) bool {
+		parsed, dust, err := Parse(Format(amount.Int, int(d)), int(d), RoundDown)
+		return err == nil && parsed.Cmp(amount.Int) == 0 && dust.Sign() == 0
+	})
+}
+
+func TestRoundDustIsExact(t *testing.T) {
+	check(t, func(num, denom base) bool {
+		if denom.Sign() == 0 {
+			return true
+		}
+		value := new(big.Rat).SetFrac(num.Int, denom.Int)
+		for _, mode := range modes {
+			rounded, dust := Round(value, mode)
+			restored := new(big.Rat).Add(new(big.Rat).SetInt(rounded), dust)
+			if restored.Cmp(value) != 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestRoundDustBounds(t *testing.T) {
+	half := big.NewRat(1, 2)
+	check(t, func(num, denom base) bool {
+		if denom.Sign() == 0 {
+			return true
+		}
+		value := new(big.Rat).SetFrac(num.Int, denom.Int)
+		abs := func(r *big.Rat) *big.Rat { return new(big.Rat).Abs(r) }
+
+		_, down := Round(value, RoundDown)
+		_, up := Round(value, RoundUp)
+		_, halfUp := Round(value, RoundHalfUp)
+		_, halfEven := Round(value, RoundHalfEven)
+
+		// truncation keeps the sign of the value, rounding up takes the opposite one
+		return abs(down).Cmp(big.NewRat(1, 1)) < 0 && down.Sign()*value.Sign() >= 0 &&
+			abs(up).Cmp(big.NewRat(1, 1)) < 0 && up.Sign()*value.Sign() <= 0 &&
+			abs(halfUp).Cmp(half) <= 0 && abs(halfEven).Cmp(half) <= 0
+	})
+}
+
+func TestConvertNeverOverpaysRoundingDown(t *testing.T) {
+	check(t, func(amount base, from, to decimals, r rate) bool {
+		amount.Abs(amount.Int)
+		payout, dust := Convert(amount.Int, int(to)-int(from), FromDec(r.Dec), RoundDown)
+
+		exact := Shift(new(big.Rat).SetInt(amount.Int), int(from)-int(to))
+		exact.Mul(exact, FromDec(r.Dec))
+		return new(big.Rat).SetInt(payout).Cmp(exact) <= 0 &&
+			new(big.Rat).Add(new(big.Rat).SetInt(payout), dust).Cmp(exact) == 0
+	})
+}
+
+func TestConvertMatchesChainDecimals(t *testing.T) {
+	// the conversion matches the previous one by fixed point decimals of the chain
+	check(t, func(amount base, r rate) bool {
+		amount.Abs(amount.Int)
+		payout, _ := Convert(amount.Int, 9, FromDec(r.Dec), RoundDown)
+		expected := sdk.NewDecFromBigIntWithPrec(amount.Int, 9).Mul(r.Dec).TruncateInt()
+		return payout.Cmp(expected.BigInt()) == 0
+	})
+}
+
+func TestFormatDecimalIsExact(t *testing.T) {
+	check(t, func(amount base, d decimals) bool {
+		value := Shift(new(big.Rat).SetInt(amount.Int), -int(d))
+		parsed, err := ParseDecimal(FormatDecimal(value))
+		return err == nil && parsed.Cmp(value) == 0
+	})
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		value    string
+		mode     Rounding
+		expected int64
+	}{
+		{"2.5", RoundDown, 2},
+		{"2.5", RoundUp, 3},
+		{"2.5", RoundHalfUp, 3},
+		{"2.5", RoundHalfEven, 2},
+		{"3.5", RoundHalfEven, 4},
+		{"-2.5", RoundHalfUp, -3},
+		{"-2.5", RoundHalfEven, -2},
+		{"-2.4", RoundUp, -3},
+		{"2.4999", RoundHalfUp, 2},
+	}
+	for _, c := range cases {
+		value, _ := ParseDecimal(c.value)
+		if rounded, _ := Round(value, c.mode); rounded.Int64() != c.expected {
+			t.Errorf("%s rounded %s: expected %d, got %s", c.value, c.mode, c.expected, rounded)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	// precision above 18 overflows int64 powers of ten
+	parsed, dust, err := Parse("1.5", 24, RoundDown)
+	if err != nil || parsed.String() != "1500000000000000000000000" || dust.Sign() != 0 {
+		t.Fatalf("unexpected parsed amount: %v, %v, %v", parsed, dust, err)
+	}
+
+	parsed, dust, err = Parse("0.0000000015", 9, RoundDown)
+	if err != nil || parsed.Int64() != 1 || dust.Cmp(big.NewRat(1, 2)) != 0 {
+		t.Fatalf("unexpected parsed amount: %v, %v, %v", parsed, dust, err)
+	}
+
+	for _, raw := range []string{"", "1e5", "0x10", "1/2", "1.2.3", " 1", "."} {
+		if _, _, err := Parse(raw, 9, RoundDown); err == nil {
+			t.Errorf("expected %q to be rejected", raw)
+		}
+	}
+}
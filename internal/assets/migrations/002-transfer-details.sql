@@ -0,0 +1,19 @@
+-- +migrate Up
+alter table transfers
+    add column created_at   timestamp with time zone not null default now(),
+    add column updated_at   timestamp with time zone not null default now(),
+    add column sent_at      timestamp with time zone,
+    add column odin_tx_hash text,
+    add column odin_height  bigint,
+    add column last_error   text,
+    add column attempts     integer                  not null default 0;
+
+-- +migrate Down
+alter table transfers
+    drop column created_at,
+    drop column updated_at,
+    drop column sent_at,
+    drop column odin_tx_hash,
+    drop column odin_height,
+    drop column last_error,
+    drop column attempts;
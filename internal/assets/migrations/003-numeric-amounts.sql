@@ -0,0 +1,17 @@
+-- +migrate Up
+alter table users
+    alter column amount type numeric(78, 0),
+    add constraint users_amount_non_negative check (amount >= 0);
+
+alter table transfers
+    alter column amount type numeric(78, 0),
+    add constraint transfers_amount_non_negative check (amount >= 0);
+
+-- +migrate Down
+alter table transfers
+    drop constraint transfers_amount_non_negative,
+    alter column amount type bigint;
+
+alter table users
+    drop constraint users_amount_non_negative,
+    alter column amount type bigint;
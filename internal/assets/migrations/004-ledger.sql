@@ -0,0 +1,108 @@
+-- +migrate Up
+create table ledger_journals
+(
+    id          bigserial,
+    kind        text                     not null,
+    user_id     bigint references users (id),
+    transfer_id bigint references transfers (id),
+    memo        text                     not null default '',
+    created_at  timestamp with time zone not null default now(),
+    PRIMARY KEY (id)
+);
+
+create table ledger_entries
+(
+    id         bigserial,
+    journal_id bigint         not null references ledger_journals (id),
+    account    text           not null,
+    user_id    bigint references users (id),
+    denom      text           not null,
+    debit      numeric(78, 0) not null default 0 check (debit >= 0),
+    credit     numeric(78, 0) not null default 0 check (credit >= 0),
+    check (debit = 0 or credit = 0),
+    PRIMARY KEY (id)
+);
+
+create index ledger_entries_account_idx on ledger_entries (account, user_id, denom);
+
+-- +migrate StatementBegin
+create function ledger_append_only() returns trigger as
+$$
+begin
+    raise exception 'table % is append-only', TG_TABLE_NAME;
+end;
+$$ language plpgsql;
+-- +migrate StatementEnd
+
+create trigger ledger_journals_append_only
+    before update or delete
+    on ledger_journals
+    for each row
+execute procedure ledger_append_only();
+
+create trigger ledger_entries_append_only
+    before update or delete
+    on ledger_entries
+    for each row
+execute procedure ledger_append_only();
+
+-- reconstruct the history of existing balances: the opening balance of a user is
+-- the current balance plus everything claimed and not refunded
+insert into ledger_journals (kind, user_id, memo)
+select 'opening', id, 'migrated balance'
+from users;
+
+insert into ledger_entries (journal_id, account, user_id, denom, debit, credit)
+select j.id, e.account, e.user_id, coalesce(u.denom, ''), e.debit, e.credit
+from ledger_journals j
+         join users u on u.id = j.user_id
+         cross join lateral (
+    select coalesce(u.amount, 0) + coalesce(sum(t.amount), 0) as amount
+    from transfers t
+    where t.user_id = u.id
+      and t.status <> 'refunded'
+    ) o
+         cross join lateral (
+    select 'bsc_holdings'::text as account, null::bigint as user_id, o.amount as debit, 0::numeric as credit
+    union all
+    select 'user', u.id, 0, o.amount
+    ) e
+where j.kind = 'opening';
+
+insert into ledger_journals (kind, user_id, transfer_id, memo)
+select 'claim', user_id, id, 'migrated transfer'
+from transfers
+order by id;
+
+insert into ledger_journals (kind, user_id, transfer_id, memo)
+select case status when 'refunded' then 'refund' else 'payout' end, user_id, id, 'migrated transfer'
+from transfers
+where status in ('refunded', 'sent')
+order by id;
+
+insert into ledger_entries (journal_id, account, user_id, denom, debit, credit)
+select j.id, e.account, e.user_id, coalesce(t.denom, ''), e.debit, e.credit
+from ledger_journals j
+         join transfers t on t.id = j.transfer_id
+         cross join lateral (
+    select 'user'::text as account, t.user_id as user_id, coalesce(t.amount, 0) as debit, 0::numeric as credit
+    where j.kind = 'claim'
+    union all
+    select 'bridge_pending', null, 0, coalesce(t.amount, 0)
+    where j.kind = 'claim'
+    union all
+    select 'bridge_pending', null, coalesce(t.amount, 0), 0
+    where j.kind in ('refund', 'payout')
+    union all
+    select 'user', t.user_id, 0, coalesce(t.amount, 0)
+    where j.kind = 'refund'
+    union all
+    select 'odin_treasury', null, 0, coalesce(t.amount, 0)
+    where j.kind = 'payout'
+    ) e
+where j.kind in ('claim', 'refund', 'payout');
+
+-- +migrate Down
+drop table ledger_entries;
+drop table ledger_journals;
+drop function ledger_append_only();
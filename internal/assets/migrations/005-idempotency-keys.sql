@@ -0,0 +1,16 @@
+-- +migrate Up
+create table idempotency_keys
+(
+    key          text,
+    request_hash text                     not null,
+    status_code  integer,
+    response     bytea,
+    created_at   timestamp with time zone not null default now(),
+    expires_at   timestamp with time zone not null,
+    PRIMARY KEY (key)
+);
+
+create index idempotency_keys_expires_at_idx on idempotency_keys (expires_at);
+
+-- +migrate Down
+drop table idempotency_keys;
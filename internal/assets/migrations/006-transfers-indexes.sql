@@ -0,0 +1,11 @@
+-- +migrate Up
+create index transfers_status_idx on transfers (status, id);
+create index transfers_user_id_idx on transfers (user_id, id);
+create index transfers_address_idx on transfers (address, id);
+create index transfers_created_at_idx on transfers (created_at);
+
+-- +migrate Down
+drop index transfers_status_idx;
+drop index transfers_user_id_idx;
+drop index transfers_address_idx;
+drop index transfers_created_at_idx;
@@ -0,0 +1,16 @@
+-- +migrate Up
+create table transfer_events
+(
+    id          bigserial,
+    transfer_id bigint                   not null references transfers (id),
+    from_status text                     not null,
+    to_status   text                     not null,
+    reason      text                     not null default '',
+    created_at  timestamp with time zone not null default now(),
+    PRIMARY KEY (id)
+);
+
+create index transfer_events_transfer_id_idx on transfer_events (transfer_id, id);
+
+-- +migrate Down
+drop table transfer_events;
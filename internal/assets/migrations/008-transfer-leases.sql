@@ -0,0 +1,13 @@
+-- +migrate Up
+alter table transfers
+    add column lease_owner      text,
+    add column lease_expires_at timestamp with time zone;
+
+create index transfers_lease_expires_at_idx on transfers (lease_expires_at) where status = 'processing';
+
+-- +migrate Down
+drop index transfers_lease_expires_at_idx;
+
+alter table transfers
+    drop column lease_owner,
+    drop column lease_expires_at;
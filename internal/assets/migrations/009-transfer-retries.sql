@@ -0,0 +1,7 @@
+-- +migrate Up
+alter table transfers
+    add column next_attempt_at timestamp with time zone;
+
+-- +migrate Down
+alter table transfers
+    drop column next_attempt_at;
@@ -0,0 +1,7 @@
+-- +migrate Up
+alter table transfers
+    add column odin_timeout_height bigint;
+
+-- +migrate Down
+alter table transfers
+    drop column odin_timeout_height;
@@ -0,0 +1,16 @@
+-- +migrate Up
+create table transfer_reviews
+(
+    id          bigserial,
+    transfer_id bigint                   not null references transfers (id),
+    decision    text                     not null,
+    operator    text                     not null,
+    reason      text                     not null,
+    created_at  timestamp with time zone not null default now(),
+    PRIMARY KEY (id)
+);
+
+create index transfer_reviews_transfer_id_idx on transfer_reviews (transfer_id, id);
+
+-- +migrate Down
+drop table transfer_reviews;
@@ -0,0 +1,25 @@
+-- +migrate Up
+alter table transfers
+    add column odin_amount numeric(78, 0),
+    add column odin_denom  text;
+
+create index transfers_outflow_idx on transfers (status, sent_at) where odin_amount is not null;
+
+create table circuit_breakers
+(
+    name       text                     not null,
+    tripped_at timestamp with time zone,
+    reason     text,
+    reset_at   timestamp with time zone,
+    reset_by   text,
+    PRIMARY KEY (name)
+);
+
+-- +migrate Down
+drop table circuit_breakers;
+
+drop index transfers_outflow_idx;
+
+alter table transfers
+    drop column odin_amount,
+    drop column odin_denom;
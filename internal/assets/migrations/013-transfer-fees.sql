@@ -0,0 +1,7 @@
+-- +migrate Up
+alter table transfers
+    add column fee numeric(78, 0);
+
+-- +migrate Down
+alter table transfers
+    drop column fee;
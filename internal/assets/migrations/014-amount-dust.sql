@@ -0,0 +1,13 @@
+-- +migrate Up
+alter table transfers
+    add column odin_dust numeric;
+
+alter table users
+    add column odin_dust numeric not null default 0;
+
+-- +migrate Down
+alter table users
+    drop column odin_dust;
+
+alter table transfers
+    drop column odin_dust;
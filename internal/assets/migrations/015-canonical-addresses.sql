@@ -0,0 +1,117 @@
+-- +migrate Up
+alter table users
+    add column merged_into  bigint references users (id),
+    add column over_claimed numeric(78, 0);
+
+-- duplicates of the same address in another case are merged into a single user: the lowercase one if it exists,
+-- the oldest one otherwise. The ledger is append-only, so the history of duplicates is kept and only compensated.
+-- +migrate StatementBegin
+do
+$$
+    declare
+        dup     record;
+        opening numeric;
+        balance numeric;
+        journal bigint;
+    begin
+        for dup in
+            select u.id, coalesce(u.denom, '') as denom, s.id as survivor_id
+            from users u
+                     cross join lateral (
+                select c.id
+                from users c
+                where lower(c.address) = lower(u.address)
+                  and c.denom is not distinct from u.denom
+                order by c.address = lower(c.address) desc, c.id
+                limit 1
+                ) s
+            where s.id <> u.id
+            order by u.id
+            loop
+                select coalesce(sum(e.credit), 0)
+                into opening
+                from ledger_entries e
+                         join ledger_journals j on j.id = e.journal_id
+                where j.kind = 'opening'
+                  and e.account = 'user'
+                  and e.user_id = dup.id;
+
+                select coalesce(sum(credit - debit), 0)
+                into balance
+                from ledger_entries
+                where account = 'user'
+                  and user_id = dup.id;
+
+                insert into ledger_journals (kind, user_id, memo)
+                values ('merge', dup.id, format('merged into user %s', dup.survivor_id))
+                returning id into journal;
+
+                -- the balance was snapshotted for every duplicate, only the one of the survivor is kept
+                if opening > 0 then
+                    insert into ledger_entries (journal_id, account, user_id, denom, debit, credit)
+                    values (journal, 'user', dup.id, dup.denom, opening, 0),
+                           (journal, 'bsc_holdings', null, dup.denom, 0, opening);
+                end if;
+
+                -- claims and refunds of the duplicate are moved to the survivor
+                balance := balance - opening;
+                if balance < 0 then
+                    insert into ledger_entries (journal_id, account, user_id, denom, debit, credit)
+                    values (journal, 'user', dup.survivor_id, dup.denom, -balance, 0),
+                           (journal, 'user', dup.id, dup.denom, 0, -balance);
+                elsif balance > 0 then
+                    insert into ledger_entries (journal_id, account, user_id, denom, debit, credit)
+                    values (journal, 'user', dup.id, dup.denom, balance, 0),
+                           (journal, 'user', dup.survivor_id, dup.denom, 0, balance);
+                end if;
+
+                update transfers set user_id = dup.survivor_id where user_id = dup.id;
+                update users set merged_into = dup.survivor_id where id = dup.id;
+            end loop;
+
+        -- the address over-claimed if the duplicates claimed more than the balance, the loss is written off and flagged
+        for dup in
+            select e.user_id as id, e.denom, -sum(e.credit - e.debit) as deficit
+            from ledger_entries e
+            where e.account = 'user'
+              and e.user_id in (select merged_into from users where merged_into is not null)
+            group by e.user_id, e.denom
+            having sum(e.credit - e.debit) < 0
+            loop
+                insert into ledger_journals (kind, user_id, memo)
+                values ('adjustment', dup.id, 'over-claimed by duplicates of the address')
+                returning id into journal;
+
+                insert into ledger_entries (journal_id, account, user_id, denom, debit, credit)
+                values (journal, 'adjustments', null, dup.denom, dup.deficit, 0),
+                       (journal, 'user', dup.id, dup.denom, 0, dup.deficit);
+
+                update users set over_claimed = dup.deficit where id = dup.id;
+            end loop;
+    end
+$$;
+-- +migrate StatementEnd
+
+update users u
+set amount = coalesce((select sum(e.credit - e.debit)
+                       from ledger_entries e
+                       where e.account = 'user'
+                         and e.user_id = u.id), 0)
+where u.id in (select id from users where merged_into is not null
+               union
+               select merged_into from users where merged_into is not null);
+
+update users
+set address = lower(address)
+where merged_into is null
+  and address <> lower(address);
+
+create unique index users_canonical_address_idx on users (lower(address), denom) where merged_into is null;
+
+-- +migrate Down
+-- merges are not reverted, as the ledger is append-only
+drop index users_canonical_address_idx;
+
+alter table users
+    drop column over_claimed,
+    drop column merged_into;
@@ -0,0 +1,9 @@
+-- +migrate Up
+alter table idempotency_keys
+    add column reservation  text,
+    add column committed_at timestamp with time zone;
+
+-- +migrate Down
+alter table idempotency_keys
+    drop column committed_at,
+    drop column reservation;
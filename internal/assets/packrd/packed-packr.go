@@ -14,7 +14,22 @@ var _ = func() error {
 	const gk = "6509bfbf1058d1bb3fd1c43272e81f7f"
 	g := packr.New(gk, "")
 	hgr, err := resolver.NewHexGzip(map[string]string{
-		"d779937177a8bb6616141958e44450c5": "1f8b08000000000000ffb491316b03310c8577ff8ab7e58e26bf2053a1194269690337640ace59170c77729064da9f5f62a7c775e9164fe67d4f427ada6cf034c58b78237457d70bdd7ee6cf23212b89bac601400c28ef1c2f4a12fdb82eb20f41481546df064e06cee32f9a52662b1591ad6a81384d40b157a57bdf7f763b34f746eb6a692bfc38ecdf9e0f47bcee8e6862685dbb757f4734f1acc3e3c754f396b52a0834f83c1a569ceca4c4b6aaa65b5ea718eead20349010f7a435c9b2c23f8b2d4ff192bed80549d7e529d07bed7da0ed92cc09ccf4270000fffff8aa5cadd3010000",
+		"08f0164126a25c7598ec93b223cd31ea": "1f8b08000000000000ff7c9031aec3200c86774ee1f145af39016bafd01959b55b792844b6a3e6f8559548a112b021beff03fb9f67f87fc953d1196e4bb82b7f4f92893770c56c0f564be6e8ab25a10d4a3eefe16f0717109a624f5e8d350935ec838c752452b6d6e70719ebfb5094d01b2f9c708a21d45d5ccb3b07d2b20cba88ed40b56f2751add449fc4e1dc367000dfdc8e9a7010000",
+		"0c552563f957e8b9fa4a78209d016873": "1f8b08000000000000ff9491c16af3400c84effb147374f89327c8e987f6504aa1047ac8c96c22d915d85aa3556ad3a72fb61bd790165a9d64ef7c2334daedf0af95daa2335eba70361e3b8fa786e11635576ca5f19b709f43110040084b9da4ce6c129bedf4b4104238492dea57e1aa3439f4d23430aed858cf9c97511985d06636233e4b96a463ef3c7c67f5653623a9638b9eec0f8871ccf38c5f2373485446075c5ace1edb0ebdf8ebf489f7a4bc2020aee2a57168ea8bcfcd9e0f0f4fff0f473cde1fa77dc3661faed18b120f37d197cb0fa1526840d21b0d8a95680ba1d1757ddfbbd46b204bdd0ff7dd878f010079e354b10e020000",
+		"0cbcf196725348725104672724a3e23b": "1f8b08000000000000ffd458cd6ee33610beeb29e690c2362a0be9a9c53ade43d13e41dbb34193237bbc14a9f2c74edebe2045c9fa4dbcbb28d026066c7186f33f1f87da6ee1c78a4e863984bfea8c4987061c3b4a046fd1d80c008009015c4b5f29a8d09c501c48390d70a4132907064b34a838da660fac496cf2f14e7d4573e09251850294afd0105ffffc4b0ecf9b5d966db7207c2d893387167409ee8c6059854184416b811430a5dd190d70661198c1640d446b185852a764f7a7b85fea1b9ac8ac150295400ef095acb379501858b414685da447d937b258c09f61338a131a200bacae5189ad56f22d07aba3e83359a7cd1be8b26f3759f882b503a6040476e0baaa5159e65014593fd67f38e6b042e57ec513a94ce8ece929464c2097cc60fc1d3ec2d7e10b0c726dc4ae5bd7352a52a7369277c29149a6384e0917ed8d623265ad593f46ed2d47a94df0067a4be163512277e00b123970cd245a8e6b5f0854baca61b5da00b3909e6c41223c5a6fae74d5e6406220ac34ba4a65e20784ee9f1b6d2d5c342990cca16112d613ce64132f46e2472af884783ba34995b1e6452aae0decd392ef96263b21a695376e87ba50da8120eb4871979436c4c9566d041a38be41a711f6531b045a9ecfbb24a922073f4dd63760076b8d7731072f9f63c6b2593b2614a975bd18e436e5d6576b2cb841416e131a77b22376622acd093186a869ab032a67082d603662baff3715d0b0a7dab57001ade012fcdb031669795c63f7485c8a2fa402ef2a19b59ad117d28a05e35c7be5026f28cf65c6403d440384af0b12bbeca1c03561832d083cbe1bbed4c08f846fc2d378fda02b0f3842caa2710dc08e53b10ea1cd5b29395458e9a94b57263d5a58af2256aff2a42b87529b8ab9b42e1a154116fc6013570f423653c1069d37110229ed4e96cdb891c0be85c65bc027c56a7bd6cea108a6005ed1bcddb13c6ff03b407d3c1bd281942c6a817ea2884a4885069fe139ec1942e93b716d3b629ddc88314d99ec4539c16c2ca21c522fceea68239fe4e5a918ee1908df495c323af4743e2b2cfdf5841d2d3f9cb514a44e2161ca4b3910f9dc49ddecb2b1305402a89ccf549c116cac5083a557a29b07baec3487bfbea200a7079999086c73fe69df9d8cdbd6b0a95d54765c2fffe9fcf57a6310f56d32ff6b32b95c16cf7781734994b617afff55bd7f5f949682ff9cc33bd15a2a795f8b50d2ce30654b34162cba1136f714a6836d4827b15b121b181b91fdd17d49eca2c4d0b06146e8d99f40b51d68c27cbf6de77b1a35ac8596526983e0ce4cf511394fe37a18f32ddc0c39870a7459461428253b9d503c38a6de0f686621a41ddbf46cfbf30b6c23211cc5615c15581227977d70e08ee69526681fce0ec3b98114ac93adfd94f4a6d6466c9f98a6cd00b2c3e23f19edeb30d175f23b87077c67760d87d252045ee0f9e37170a689bf751860e2e2ad0bf79f5e7fae0625747c9bb911a6625b7ddf38f06fa3d11489eefece9e95cdcf5881ef42d23c1c4d51a8276fb3cb3e8686c1dd7cdfdffe382ea4a7ece969377fd3fd5d892c1ba8f65950ceaad43bddb8dcb6c742b54efc79b85fbfbe7517bb781f6f57cd189f20b918363789877b7aacd42bd293833489fd26ccd80d23dfc47d74134dcf9b6c5e54636680b276e3cbe7d1ce5d967183b1b414fded1148097c6d541e38535a1167f290d80f245ec36d2ebd331a8a4aadb659f0cbcbd0d7fd32fb4ddf54a8bb68b68d0362f0de84b9de61182d2cb8a5d73a9930bafed8da5db6f0822c6e9f79cf954fa83d4776d93f030009e18cc37d130000",
+		"3167fc291eea7eca6691fc812a43d5fa": "1f8b08000000000000ff84ce41aac2401004d0fd9ca2f63f3941b6ff0aae439b69b4313d3d4c4a633cbde02aa8e0b2a8827a7d8f3fb753132a0e35c94c6da01c678565f51ad4326de345b7250180e48c29e6ab17345db4dd841605a0ded9bd2fa6703752f32804cd75a178c56a3cbf221e517448698ff88fb5fc66e416f5db4bf7d1ee94437a0e00960f7386ee000000",
+		"4288cf102cc229150778b03cf0bc6366": "1f8b08000000000000ff7ccd310e02310c44d1dea7981eed09b6e50ad4c8100391622772062de2f4082a1aa846d3fcb72cd879bda6d27018a28d96a09e9a81a9312f965300404bc1b9b7bb07c21e3c2a693ede0b56b749f581adf2f6b978f6b055e43bbfef5bfc014af6f14358e53500da5321c7a7000000",
+		"47ee4362708dc27f830dda10138a696c": "1f8b08000000000000ff8c904b6ec3300c44f73cc5ecd2a2c9098cee7a85ae0dd69a26026c49201958e8e98b7ae322fd20da097c7843cee984a7259f4d83786da273d010fa3613615afc9de602009a12a63a5f978299ea1ceb5a68d85eb0c7f1778abd65a38f1a88bcd043978635c765fbe2a3160e2293f16b815c12fb9e3bde2ac69c3a6ad9093cdc228f582f34c243e3ea78c6a1599de89ecbf930887cbff7a5ae4592d57657f020ffb4b3597ed673fc63b89b07f91c00654e719582010000",
+		"81f3fae7c9aa8fcb89badc3c5fe0533d": "1f8b08000000000000ffac91c16a43211444f77ec52c5b9a40f7d9f617ba7edcc49ba7a057d179bcd0af2f690a0931cdaaeee48c079cd96ef196e3dc848acfea24511b28fba46013eb476ddd0180788f43494b361c9a0ad54f42008c593b2557ac91e1e78aaf620a2b842d29c1eb51964458595f5e37f7b2a5faff9375355e4c4f64c3abe2a34d3c4d417a00f5c4c789a0710e04f6718e366692744eda5a69c0638b909a2bfb598e68d459cfd9bb337cf57de7dced4c1f65b52743f956eab8d46660d7e247f6dbe3086eabfa835e6a1ae1b59f9109a9b9b2efdcf700546a850390020000",
+		"999f163b7ef60215888af9c1ce8bb43c": "1f8b08000000000000ff84924f4bf34010c6effb299e63c29bc27b53c849d0838820050f3d8569766a17b333617742aa9f5ed2586bb15672c8eece9fe7f7ecec62817f31bc2432c673efa8334e305a770c4b2479c3293b0020efd16a374481fa200d451dc42043e414dae2eabac2ffb23a9bea593402c63bab9d6b134f62413cef8e1a8d0eb6e9746c82df41e51840918d6cc815328b356425c62d273ea10819a2134cd71d1566176d48ed10ac5927a6d7c94cb167148a3cfd31737d2e4fbf43cbd995a5d0f7ec1b3258889c8d628f31d876bfc5bb0acf898929ab7cb53e1c669ee8813faba7c4f5db49f5d3f2fef166b9c2c3dd0ac5845ebab276eefbf06e7514e793f6bff8aedd1cbd70efb5bb30ff7df1cf07509d0f7a168db5fb1800ee46f2895e020000",
+		"a0717c1292c899ac042cab5564b71de0": "1f8b08000000000000ff8cce31aa02410c87f13ea7f8f78f85d74feb15ac256eb23290c92c990c5e5fec1459f100df8f6f59f0d7ea2d3815e79dd85203c9575364b08f4d631000b008d66eb339ba54bfc81c099f4da3ae85dec2397e89e0fd09984174e36989ff42f4fa73ea773f8025fafe2117faf27f503c06008dfc713f00010000",
+		"abf64b461f08e67d5061763988e85c6b": "1f8b08000000000000ff8c91414bc4301085eff915efd8a2fb0bf624e8414490050f7b0ad9e6b90d6d93984c69ebaf97b674575904e73479bcf926ccdbed70d7b9733242bc475525ce9d98534b38cb2e06a1af26dd70caaa5000d070c2a584a3dc2f72e267cf2cba36b95ee4cdf2ab7c10f8be6dd7992c46faacab6009382f3c336db41c83cf9c7b9c26a159f5f583561b0120ae6316d3450c4eeae589afe079d902cb0fd3b7021f86a25c091ca34bccff24ac336f87e7d787c3112f4f47140da752957bb51dcb79cbf1e658faba473b3b22f81b0b8aab67e6fdcce2310c5ed914e21f59ecd5f70022e95eddba010000",
+		"b319f8fed07c5e70f2814fc8fef4f064": "1f8b08000000000000ffb491416bc3300c85effe15efd684b5bf20a7c17a2863632be4d053516da51812b95832dbcf1f89bb915d76dbc598ef3d09e969b7c3c314af998cd1df9ccf3cff8c2e23a32867758d038018e617b8c4ab728e346e174c21645685f1a7419241caf82d4da9882d1551acb2c0922660b157d2bf1edefb3d9a7ba36db5b4557c3b1e5e1e8f273cef4f6862685ddbb9df235a26d1e1ffc754232b5a09020f5446c346929d95c536d534e7758ee1de0a9907ce2c9eb526b9acf0c762eb533ca50f7121a7dbfa14f0a49e02776be5270178524f81bbaf0100f8aa5cadd3010000",
+		"b444bf4b23bf4f0ae39b8665183c5357": "1f8b08000000000000ff8c91416bc3300c85effe15efd694b5bfa0a7c1761863300a3bf4149c5ac9048e1c6c6509fbf523499385b687ea24e1f73d21bffd1e4f3557d12ae1ab31e74843a7b6f0048d56524931a71f124d263300c00e4b155c258a6cfd6e7c5a007628b862d159b82a090a69bd47a49222c999d2b2292163b79dccca18ea3ca9d53641a9bf67f56f76d91f66008f22916c0a32740f207054dad62b369b899ebecbe55601e59a92daba41c7fa3d8ef80d42b7b4842ebb1cf9797cfb783e9ef0fe7a1a4f37db8399436071d45f87902f33bb9c5d8f20d712642bcd0eec06cf75ce2fa113e36268eee77c307f0300321fff6515020000",
+		"c037eaf1e4a4c0168866e66996eed24a": "1f8b08000000000000ffac91c18ac2301086ef798affd8b22df4b60b653df90a9e4b9a8e35d84c4a32517c7b0f1151c1a2e87598f9bf6f66ea1a3fce8e410b61332b3d090588ee27428a14a202805c357e4a8ea19d4f2c90d34ce0e4285853fcfe5568ca2a370f038ce728415b969cd2e5a18e3d774ca3167b20981d993d8a4bdeea1f4dd9aa3b03099ae3f61b16d7a4374c6e4fb3f6475e501b829f5fc655cbdbf476b42cad7af28b47548a9f61ce03000525f90601020000",
+		"cfe3a529bd6f7b9ad8c957758cee9230": "1f8b08000000000000ff7cccb10dc3400805d09e297e1f7902b75921b585033923dd8185b1b27eda54b7c05b163c86b5e452bc4ee25e9a28debba292fdfa685e04002c8277f47b3842ccb7b2a171d776a8b5a3b05b33af95e81f7cc6d727a4649c1373a5df00a48e22ae9d000000",
+		"ee5d25e4e64b1e3b30b9fbe791f20f5e": "1f8b08000000000000ff7ccc310e42210c06e0bda7f8478dbec44d1356afe0012a144302859412afefcae405bee3c0a5958fb10b5e83b8ba189cdf55e0c63ab3d82400e094107b5d4d9145a0ab899578ba3faeb89d03d10e3dfb57ff50c9fad8ac40bf0100cded63af85000000",
+		"fab1166ba8ccb2b1f4034411b216a753": "1f8b08000000000000ffbc574d6fe33613beeb57cc212f286315c3b7b7b09b05b2685014ed16c5767bd893419363995e8a54c9616df7d717a4284b7194af6d505f6291f3f1ccccf38c9ceb6b78d7a8da7142f8a32d84c3f88df84623689435baf5de0667b8f6455900002809e7cf46d51e9de2ba4a575f95395f121ea9ff7eef632c81093abb048f6e9d436e54ad0c81c32d3a34027dbaf5502a39ebacc971e3b79dc743ebfe76ecd160635f0c09246e79d0048c75de5d3be49a1300a9063df1a68583a25d7a84bfadc187dec61eca9cfeb74f3f7dbcfdf4057ebefb924015b35531d96434e4144ef4f8a2c57918a3063c2862d4918b098efac285b0c1d0445b1e9dcff3e391686c03f06c50891b956f4d68d02951feffbb0a16b387bd5c80d8a1f80a65e7f3fe06163999702815bd2e48f619471947bf81055807d96a307a6a8aca483c5e4c719dbbbb56f208d65cdc4299afabbebb55d7b9c88db11e7f274ed8a0a10f582bd327dc0623480d5179dba2916b6bf4a99c81430ace442da8ba4607dc175757c5260588a538ae3c021e05b62908eb58f83f501eba48d73112abe0f38febcfb71f7eb95bff7afbf16e55a091abe2ea0a343775e03542abdbdaffa957d390ef8c1c889eb15cb0718c3ccd62835beb10422b639dd681448d84e9ce9a4bf774bcb50e908b1d387b28f088221042ebac40191c4eb668f518aef3f0be0956f6fe17a8aeafc1a1b0c6930b828076083be5c9ba13d82de0517952a6860dd73c8a7b992c6c8b66741a2d79a215281f27136d44700e0d9d6d5a1d3ce05fe84eb48bbe4273d5a0046e6412a0c36d301265a18c4747a00cd9cbee431997fd88c171d1ce0a8f1a0501cbb0580591dd2cd343f61058b175b649ce7e554ce5c9ed8432274c391ed14dfcb3515465e19e51ece7d104e767379c9f1d85e51abdc032cc730cc666d13887c27917ac037a59fcbee8171becad3279118648873057126e60dfa71a2c85b3de77f69a133aaea1dbf619ee08126fe2724e1bf1dd70ee435352be9ba54beea17b4c7112d4e125d81d1e76e810a887033710e6675471e234f7c42978f8fe3db07ef42c19ccc0be1c3edb78b1de592d95a93d5b2ed31b80fb616871252f97f92dc2fd30449b6b02eefb492e96cbbcd4e3611e4544114cdc5a5ceb7ba9632856a5ca2a580c11731558745dd8cf2369e166e0e734f91e2579dfdc09c62711b191ed7de2f79e99f9fda32fac93e860730225df0c8ce01e214ff5b043339a2bd0e899016a8fc05a7eb28118a091af2fa06b6dcea60c9443b20a9847436cf67c95ff99dee92df47eae1e286a9e7acdf7e7afd23d8bd54d096610ed582da34a466be205d2b9d440c7d82754b5714ad6b88e6fc3a8954ec1495f9318de34c964860a160f938c28c7aa339767cf6f8b737b5f5952cef544022b95599343ee833b7d5bdf7219d30b2c95dc2f9c89da2f7e47fe600fa690ceb693ff72ac26ae7ae2e7bb277f72ae8a7f06006ad55da4440e0000",
 	})
 	if err != nil {
 		panic(err)
@@ -23,7 +38,22 @@ var _ = func() error {
 
 	func() {
 		b := packr.New("migrations", "./migrations")
-		b.SetResolver("001-initial.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "d779937177a8bb6616141958e44450c5"})
+		b.SetResolver("001-initial.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "b319f8fed07c5e70f2814fc8fef4f064"})
+		b.SetResolver("002-transfer-details.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "81f3fae7c9aa8fcb89badc3c5fe0533d"})
+		b.SetResolver("003-numeric-amounts.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "c037eaf1e4a4c0168866e66996eed24a"})
+		b.SetResolver("004-ledger.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "fab1166ba8ccb2b1f4034411b216a753"})
+		b.SetResolver("005-idempotency-keys.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "abf64b461f08e67d5061763988e85c6b"})
+		b.SetResolver("006-transfers-indexes.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "08f0164126a25c7598ec93b223cd31ea"})
+		b.SetResolver("007-transfer-events.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "b444bf4b23bf4f0ae39b8665183c5357"})
+		b.SetResolver("008-transfer-leases.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "47ee4362708dc27f830dda10138a696c"})
+		b.SetResolver("009-transfer-retries.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "4288cf102cc229150778b03cf0bc6366"})
+		b.SetResolver("010-transfer-timeout-height.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "cfe3a529bd6f7b9ad8c957758cee9230"})
+		b.SetResolver("011-transfer-reviews.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "0c552563f957e8b9fa4a78209d016873"})
+		b.SetResolver("012-outflow-breaker.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "999f163b7ef60215888af9c1ce8bb43c"})
+		b.SetResolver("013-transfer-fees.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "ee5d25e4e64b1e3b30b9fbe791f20f5e"})
+		b.SetResolver("014-amount-dust.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "a0717c1292c899ac042cab5564b71de0"})
+		b.SetResolver("015-canonical-addresses.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "0cbcf196725348725104672724a3e23b"})
+		b.SetResolver("016-idempotency-commit.sql", packr.Pointer{ForwardBox: gk, ForwardPath: "3167fc291eea7eca6691fc812a43d5fa"})
 	}()
 	return nil
 }()
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/fees"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// listFees logs fees accumulated by denoms
+func listFees(ctx context.Context, cfg config.Config) error {
+	balances, err := fees.New(cfg, postgres.NewStorage(cfg)).Balances(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, balance := range balances {
+		cfg.Logger().WithFields(logrus.Fields{
+			"denom":  balance.Denom,
+			"amount": balance.Amount.String(),
+		}).Info("Accumulated fees")
+	}
+	return nil
+}
+
+// sweepFees moves all the accumulated fees of the denom out of the bridge on behalf of the operator
+func sweepFees(ctx context.Context, cfg config.Config, denom, operator, memo string) error {
+	swept, err := fees.New(cfg, postgres.NewStorage(cfg)).Sweep(ctx, denom, operator, memo)
+	if err != nil {
+		return err
+	}
+	if swept.Sign() == 0 {
+		return errors.Errorf("no %s fees to sweep", denom)
+	}
+
+	cfg.Logger().WithFields(logrus.Fields{
+		"denom":    denom,
+		"amount":   swept.String(),
+		"operator": operator,
+	}).Info("Fees swept")
+	return nil
+}
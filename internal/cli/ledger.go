@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"math/big"
+)
+
+// rebuildBalances recalculates cached users balances from the ledger
+func rebuildBalances(ctx context.Context, cfg config.Config) error {
+	changed, err := ledger.New(postgres.NewStorage(cfg)).Rebuild(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to rebuild balances")
+	}
+
+	cfg.Logger().WithField("changed", changed).Info("Balances rebuilt")
+	return nil
+}
+
+// adjustBalance posts manual adjustment of the user balance
+func adjustBalance(ctx context.Context, cfg config.Config, userID int64, rawAmount, memo string) error {
+	delta, ok := new(big.Int).SetString(rawAmount, 10)
+	if !ok {
+		return errors.Errorf("failed to parse amount: %s", rawAmount)
+	}
+
+	storage := postgres.NewStorage(cfg)
+	user, err := storage.Users().GetUserById(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get user")
+	}
+	if user == nil {
+		return errors.Errorf("user not found: %d", userID)
+	}
+
+	if err := ledger.New(storage).Adjust(ctx, *user, delta, memo); err != nil {
+		return errors.Wrap(err, "failed to adjust balance")
+	}
+
+	cfg.Logger().WithFields(logrus.Fields{
+		"user_id": userID,
+		"amount":  delta.String(),
+	}).Info("Balance adjusted")
+	return nil
+}
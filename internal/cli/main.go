@@ -4,9 +4,12 @@ import (
 	"context"
 	"github.com/alecthomas/kingpin"
 	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
 	"github.com/bsc-bridge-svc/internal/data/migrate"
 	"github.com/bsc-bridge-svc/internal/services/server"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func Run(args []string) bool {
@@ -23,10 +26,46 @@ func Run(args []string) bool {
 	app := kingpin.New("bsc-bridge-svc", "")
 
 	runCmd := app.Command("run", "run command")
+	runAPICmd := runCmd.Command(string(server.ModeAPI), "serve requests only")
+	runWorkerCmd := runCmd.Command(string(server.ModeWorker), "run background jobs only")
+	runAllCmd := runCmd.Command(string(server.ModeAll), "serve requests and run background jobs").Default()
 	migrateDBCmd := app.Command("migrate", "migrate command")
 	migrateDBUpCmd := migrateDBCmd.Command(migrate.Up, "migrate db up")
 	migrateDBDownCmd := migrateDBCmd.Command(migrate.Down, "migrate db down")
 
+	ledgerCmd := app.Command("ledger", "ledger command")
+	ledgerRebuildCmd := ledgerCmd.Command("rebuild", "rebuild cached users balances from the ledger")
+	ledgerAdjustCmd := ledgerCmd.Command("adjust", "manually adjust balance of the user")
+	ledgerAdjustUserID := ledgerAdjustCmd.Arg("user-id", "id of the user").Required().Int64()
+	ledgerAdjustAmount := ledgerAdjustCmd.Arg("amount", "signed amount in base units").Required().String()
+	ledgerAdjustMemo := ledgerAdjustCmd.Flag("memo", "reason of the adjustment").Required().String()
+
+	reviewCmd := app.Command("review", "manual review of held transfers")
+	reviewListCmd := reviewCmd.Command("list", "list transfers waiting for the review")
+	reviewApproveCmd := reviewCmd.Command("approve", "return the held transfer to the queue")
+	reviewApproveID := reviewApproveCmd.Arg("transfer-id", "id of the transfer").Required().Int64()
+	reviewApproveOperator := reviewApproveCmd.Flag("operator", "name of the operator").Envar("USER").Required().String()
+	reviewApproveReason := reviewApproveCmd.Flag("reason", "reason of the decision").Required().String()
+	reviewRejectCmd := reviewCmd.Command("reject", "refund the held transfer")
+	reviewRejectID := reviewRejectCmd.Arg("transfer-id", "id of the transfer").Required().Int64()
+	reviewRejectOperator := reviewRejectCmd.Flag("operator", "name of the operator").Envar("USER").Required().String()
+	reviewRejectReason := reviewRejectCmd.Flag("reason", "reason of the decision").Required().String()
+
+	pauseCmd := app.Command("pause", "pause claims or payouts on all replicas")
+	pauseTarget := pauseCmd.Arg("target", "what to pause").Required().Enum(data.PauseTargets...)
+	pauseOperator := pauseCmd.Flag("operator", "name of the operator").Envar("USER").Required().String()
+	pauseReason := pauseCmd.Flag("reason", "reason of the pause").Required().String()
+	resumeCmd := app.Command("resume", "resume paused claims or payouts")
+	resumeTarget := resumeCmd.Arg("target", "what to resume").Required().Enum(data.PauseTargets...)
+	resumeOperator := resumeCmd.Flag("operator", "name of the operator").Envar("USER").Required().String()
+
+	feesCmd := app.Command("fees", "bridge fees command")
+	feesListCmd := feesCmd.Command("list", "list fees accumulated by denoms")
+	feesSweepCmd := feesCmd.Command("sweep", "sweep all the accumulated fees of the denom")
+	feesSweepDenom := feesSweepCmd.Arg("denom", "binance token of the fees").Required().String()
+	feesSweepOperator := feesSweepCmd.Flag("operator", "name of the operator").Envar("USER").Required().String()
+	feesSweepMemo := feesSweepCmd.Flag("memo", "destination or reason of the sweep").Required().String()
+
 	cmd, err := app.Parse(args[1:])
 	if err != nil {
 		log.WithError(err).Error("failed to parse arguments")
@@ -34,19 +73,50 @@ func Run(args []string) bool {
 	}
 
 	switch cmd {
-	case runCmd.FullCommand():
-		if err := server.New(cfg, ctx).Run(); err != nil {
-			log.WithError(err).Error("failed to run bridge service")
-			return false
-		}
+	case runAPICmd.FullCommand():
+		err = runServer(ctx, cfg, server.ModeAPI)
+	case runWorkerCmd.FullCommand():
+		err = runServer(ctx, cfg, server.ModeWorker)
+	case runAllCmd.FullCommand():
+		err = runServer(ctx, cfg, server.ModeAll)
 	case migrateDBUpCmd.FullCommand():
 		_, err = migrate.MigrateUp(cfg)
 	case migrateDBDownCmd.FullCommand():
 		_, err = migrate.MigrateDown(cfg)
+	case ledgerRebuildCmd.FullCommand():
+		err = rebuildBalances(ctx, cfg)
+	case ledgerAdjustCmd.FullCommand():
+		err = adjustBalance(ctx, cfg, *ledgerAdjustUserID, *ledgerAdjustAmount, *ledgerAdjustMemo)
+	case reviewListCmd.FullCommand():
+		err = listHeld(ctx, cfg)
+	case reviewApproveCmd.FullCommand():
+		err = reviewTransfer(ctx, cfg, true, *reviewApproveID, *reviewApproveOperator, *reviewApproveReason)
+	case reviewRejectCmd.FullCommand():
+		err = reviewTransfer(ctx, cfg, false, *reviewRejectID, *reviewRejectOperator, *reviewRejectReason)
+	case pauseCmd.FullCommand():
+		err = togglePause(ctx, cfg, true, *pauseTarget, *pauseOperator, *pauseReason)
+	case resumeCmd.FullCommand():
+		err = togglePause(ctx, cfg, false, *resumeTarget, *resumeOperator, "")
+	case feesListCmd.FullCommand():
+		err = listFees(ctx, cfg)
+	case feesSweepCmd.FullCommand():
+		err = sweepFees(ctx, cfg, *feesSweepDenom, *feesSweepOperator, *feesSweepMemo)
 	default:
 		log.WithField("command", cmd).Error("Unknown command")
 		return false
 	}
 
+	if err != nil {
+		log.WithError(err).WithField("command", cmd).Error("command failed")
+		return false
+	}
+
 	return true
 }
+
+// runServer runs the service until the termination signal, then stops it gracefully
+func runServer(ctx context.Context, cfg config.Config, mode server.Mode) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return server.New(cfg, ctx).Run(mode)
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/pause"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// togglePause pauses or resumes the target on behalf of the operator, all replicas pick the change up on the next check
+func togglePause(ctx context.Context, cfg config.Config, paused bool, target, operator, reason string) error {
+	service := pause.New(postgres.NewStorage(cfg))
+
+	var changed bool
+	var err error
+	if paused {
+		changed, err = service.Pause(ctx, target, operator, reason)
+	} else {
+		changed, err = service.Resume(ctx, target, operator)
+	}
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return errors.Errorf("%s are already in the requested state", target)
+	}
+
+	cfg.Logger().WithFields(logrus.Fields{
+		"target":   target,
+		"operator": operator,
+		"paused":   paused,
+	}).Info("Pause toggled")
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/review"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const heldPageLimit = 100
+
+// listHeld logs all transfers waiting for the manual review with the reasons they are held for
+func listHeld(ctx context.Context, cfg config.Config) error {
+	storage := postgres.NewStorage(cfg)
+	service := review.New(cfg, storage)
+	log := cfg.Logger()
+
+	page := data.PageParams{Limit: heldPageLimit, Order: data.OrderAsc}
+	for {
+		transfers, err := service.Held(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		for _, transfer := range transfers {
+			reviews, err := storage.Reviews().SelectReviews(ctx, transfer.ID)
+			if err != nil {
+				return errors.Wrap(err, "failed to select transfer reviews")
+			}
+
+			reason := ""
+			if len(reviews) > 0 {
+				reason = reviews[len(reviews)-1].Reason
+			}
+			log.WithFields(logrus.Fields{
+				"transfer_id": transfer.ID,
+				"user_id":     transfer.UserID,
+				"address":     transfer.Address,
+				"amount":      transfer.Amount.String(),
+				"denom":       transfer.Denom,
+				"created_at":  transfer.CreatedAt,
+				"reason":      reason,
+			}).Info("Held transfer")
+		}
+
+		if uint64(len(transfers)) < page.Limit {
+			return nil
+		}
+		page.Cursor = &transfers[len(transfers)-1].ID
+	}
+}
+
+// reviewTransfer approves or rejects the held transfer on behalf of the operator
+func reviewTransfer(ctx context.Context, cfg config.Config, approve bool, id int64, operator, reason string) error {
+	service := review.New(cfg, postgres.NewStorage(cfg))
+
+	decide := service.Reject
+	if approve {
+		decide = service.Approve
+	}
+
+	transfer, err := decide(ctx, id, operator, reason)
+	if err != nil {
+		return errors.Wrapf(err, "failed to review transfer %d", id)
+	}
+
+	cfg.Logger().WithFields(logrus.Fields{
+		"transfer_id": transfer.ID,
+		"operator":    operator,
+		"status":      transfer.Status,
+	}).Info("Transfer reviewed")
+	return nil
+}
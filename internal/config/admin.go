@@ -0,0 +1,49 @@
+package config
+
+import "crypto/subtle"
+
+type Admin interface {
+	// AdminOperator returns the operator authenticated by the admin token
+	AdminOperator(token string) (Operator, bool)
+}
+
+// Operator is the person managing the bridge, roles restrict the admin routes the operator may use
+type Operator struct {
+	Name  string
+	Roles []string
+}
+
+// HasRole checks whether the operator is allowed to use the routes of the role
+func (o Operator) HasRole(role string) bool {
+	for _, granted := range o.Roles {
+		if granted == role {
+			return true
+		}
+	}
+	return false
+}
+
+type admin struct {
+	// Operators maps names of operators to their admin tokens and roles
+	Operators map[string]adminOperator `yaml:"operators"`
+}
+
+type adminOperator struct {
+	Token string   `yaml:"token"`
+	Roles []string `yaml:"roles"`
+}
+
+func (a *admin) AdminOperator(token string) (Operator, bool) {
+	if a == nil || token == "" {
+		return Operator{}, false
+	}
+	for name, operator := range a.Operators {
+		if subtle.ConstantTimeCompare([]byte(operator.Token), []byte(token)) == 1 {
+			return Operator{
+				Name:  name,
+				Roles: append([]string(nil), operator.Roles...),
+			}, true
+		}
+	}
+	return Operator{}, false
+}
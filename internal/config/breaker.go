@@ -0,0 +1,67 @@
+package config
+
+import (
+	"math/big"
+	"time"
+)
+
+type Breaker interface {
+	// BreakerWindow is the rolling window the outflow caps are applied to
+	BreakerWindow() time.Duration
+	// BreakerOdinLimits returns limits of payouts by odin denoms, amounts are in base units
+	BreakerOdinLimits() map[string]Limit
+	// BreakerBinanceLimits returns limits of transfers by binance tokens, amounts are in base units
+	BreakerBinanceLimits() map[string]Limit
+}
+
+// Limit restricts the outflow, nil amounts are not limited
+type Limit struct {
+	// Cap is the maximum outflow within the window
+	Cap *big.Int `yaml:"cap"`
+	// MaxTransfer is the maximum amount of a single transfer
+	MaxTransfer *big.Int `yaml:"max_transfer"`
+}
+
+type breaker struct {
+	Window  time.Duration    `yaml:"window"`
+	Odin    map[string]Limit `yaml:"odin"`
+	Binance map[string]Limit `yaml:"binance"`
+}
+
+const defaultBreakerWindow = 24 * time.Hour
+
+func (b *breaker) BreakerWindow() time.Duration {
+	if b == nil || b.Window <= 0 {
+		return defaultBreakerWindow
+	}
+	return b.Window
+}
+
+func (b *breaker) BreakerOdinLimits() map[string]Limit {
+	if b == nil {
+		return nil
+	}
+	return copyLimits(b.Odin)
+}
+
+func (b *breaker) BreakerBinanceLimits() map[string]Limit {
+	if b == nil {
+		return nil
+	}
+	return copyLimits(b.Binance)
+}
+
+// copyLimits prevents callers from mutating the configured amounts
+func copyLimits(limits map[string]Limit) map[string]Limit {
+	result := make(map[string]Limit, len(limits))
+	for denom, limit := range limits {
+		if limit.Cap != nil {
+			limit.Cap = new(big.Int).Set(limit.Cap)
+		}
+		if limit.MaxTransfer != nil {
+			limit.MaxTransfer = new(big.Int).Set(limit.MaxTransfer)
+		}
+		result[denom] = limit
+	}
+	return result
+}
@@ -9,10 +9,12 @@ import (
 
 type Databaser interface {
 	DB() *sql.DB
+	DBInMemory() bool
 }
 
 type databaser struct {
-	Url string `yaml:"url"`
+	Url      string `yaml:"url"`
+	InMemory bool   `yaml:"in_memory"`
 
 	cache struct {
 		db *sql.DB
@@ -37,3 +39,8 @@ func (d *databaser) DB() *sql.DB {
 	})
 	return d.cache.db
 }
+
+// DBInMemory defines whether the in-memory storage is used instead of the database
+func (d *databaser) DBInMemory() bool {
+	return d.InMemory
+}
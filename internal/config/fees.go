@@ -0,0 +1,44 @@
+package config
+
+import (
+	"math/big"
+)
+
+type Fees interface {
+	// Fee returns the fee policy of the binance token, false if transfers of the token are free
+	Fee(denom string) (FeePolicy, bool)
+}
+
+// FeePolicy defines the bridge fee charged from the transfer, amounts are in base units of the token
+type FeePolicy struct {
+	// Fixed is charged from every transfer
+	Fixed *big.Int `yaml:"fixed"`
+	// Percent of the transfer amount is charged in addition to the fixed part, e.g. "0.25"
+	Percent *big.Rat `yaml:"percent"`
+	// Min and Max bound the total fee, nil bounds are not applied
+	Min *big.Int `yaml:"min"`
+	Max *big.Int `yaml:"max"`
+}
+
+type fees map[string]FeePolicy
+
+func (f fees) Fee(denom string) (FeePolicy, bool) {
+	policy, ok := f[denom]
+	if !ok {
+		return FeePolicy{}, false
+	}
+	return policy.copy(), true
+}
+
+// copy prevents callers from mutating the configured amounts
+func (p FeePolicy) copy() FeePolicy {
+	for _, amount := range []**big.Int{&p.Fixed, &p.Min, &p.Max} {
+		if *amount != nil {
+			*amount = new(big.Int).Set(*amount)
+		}
+	}
+	if p.Percent != nil {
+		p.Percent = new(big.Rat).Set(p.Percent)
+	}
+	return p
+}
@@ -0,0 +1,34 @@
+package config
+
+import "time"
+
+type Idempotency interface {
+	IdempotencyRetention() time.Duration
+	// IdempotencyInProgressTTL is how long the key is reserved for the request being processed,
+	// so the reservation of the crashed instance expires
+	IdempotencyInProgressTTL() time.Duration
+}
+
+type idempotency struct {
+	Retention     time.Duration `yaml:"retention"`
+	InProgressTTL time.Duration `yaml:"in_progress_ttl"`
+}
+
+const (
+	defaultIdempotencyRetention     = 24 * time.Hour
+	defaultIdempotencyInProgressTTL = time.Minute
+)
+
+func (i *idempotency) IdempotencyRetention() time.Duration {
+	if i == nil || i.Retention <= 0 {
+		return defaultIdempotencyRetention
+	}
+	return i.Retention
+}
+
+func (i *idempotency) IdempotencyInProgressTTL() time.Duration {
+	if i == nil || i.InProgressTTL <= 0 {
+		return defaultIdempotencyInProgressTTL
+	}
+	return i.InProgressTTL
+}
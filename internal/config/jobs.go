@@ -0,0 +1,72 @@
+package config
+
+import "time"
+
+// names of the background jobs
+const (
+	JobLeader              = "leader"
+	JobSend                = "send"
+	JobRefund              = "refund"
+	JobRecover             = "recover"
+	JobResolve             = "resolve"
+	JobIdempotencyCleanup  = "delete_expired_idempotency_keys"
+	defaultJobConcurrency  = 1
+	defaultSendConcurrency = 4
+)
+
+var defaultJobPeriods = map[string]time.Duration{
+	JobLeader:             5 * time.Second,
+	JobSend:               5 * time.Second,
+	JobRefund:             10 * time.Second,
+	JobRecover:            time.Minute,
+	JobResolve:            30 * time.Second,
+	JobIdempotencyCleanup: time.Hour,
+}
+
+type Jobs interface {
+	// Job returns settings of the background job, the unknown job is disabled
+	Job(name string) Job
+}
+
+// Job defines how the background job is run
+type Job struct {
+	Enabled bool
+	Period  time.Duration
+	// Concurrency is the number of items processed by the job in parallel
+	Concurrency int
+}
+
+type jobs map[string]*job
+
+type job struct {
+	Enabled     *bool         `yaml:"enabled"`
+	Period      time.Duration `yaml:"period"`
+	Concurrency int           `yaml:"concurrency"`
+}
+
+func (j jobs) Job(name string) Job {
+	period, ok := defaultJobPeriods[name]
+	if !ok {
+		return Job{}
+	}
+
+	result := Job{Enabled: true, Period: period, Concurrency: defaultJobConcurrency}
+	if name == JobSend {
+		result.Concurrency = defaultSendConcurrency
+	}
+
+	settings := j[name]
+	if settings == nil {
+		return result
+	}
+	if settings.Enabled != nil {
+		result.Enabled = *settings.Enabled
+	}
+	if settings.Period > 0 {
+		result.Period = settings.Period
+	}
+	if settings.Concurrency > 0 {
+		result.Concurrency = settings.Concurrency
+	}
+	return result
+}
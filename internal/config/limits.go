@@ -0,0 +1,47 @@
+package config
+
+import (
+	"math/big"
+	"time"
+)
+
+type Limits interface {
+	// AddressLimits returns limits of claims of the binance token per binance address and per odin address,
+	// amounts are in base units of the token
+	AddressLimits(denom string) (binance, odin AddressLimit)
+}
+
+// AddressLimit restricts claims of a single address, zero values are not limited
+type AddressLimit struct {
+	// MinClaim is the minimum amount of a claim, smaller ones are dust
+	MinClaim *big.Int `yaml:"min_claim"`
+	// MaxClaim is the maximum amount of a claim
+	MaxClaim *big.Int `yaml:"max_claim"`
+	// MaxTotal is the maximum amount claimed within Window, e.g. 24h or 168h
+	MaxTotal *big.Int      `yaml:"max_total"`
+	Window   time.Duration `yaml:"window"`
+	// Cooldown is the minimum time between claims
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+type tokenLimits struct {
+	Binance AddressLimit `yaml:"binance"`
+	Odin    AddressLimit `yaml:"odin"`
+}
+
+type limits map[string]tokenLimits
+
+func (l limits) AddressLimits(denom string) (binance, odin AddressLimit) {
+	token := l[denom]
+	return token.Binance.copy(), token.Odin.copy()
+}
+
+// copy prevents callers from mutating the configured amounts
+func (a AddressLimit) copy() AddressLimit {
+	for _, amount := range []**big.Int{&a.MinClaim, &a.MaxClaim, &a.MaxTotal} {
+		if *amount != nil {
+			*amount = new(big.Int).Set(*amount)
+		}
+	}
+	return a
+}
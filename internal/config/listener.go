@@ -1,13 +1,27 @@
 package config
 
+import "time"
+
 type Listener interface {
 	Address() string
+	// ShutdownTimeout is how long in-flight requests and jobs may finish after the stop signal
+	ShutdownTimeout() time.Duration
 }
 
 type listener struct {
-	Addr string `yaml:"addr"`
+	Addr     string        `yaml:"addr"`
+	Shutdown time.Duration `yaml:"shutdown_timeout"`
 }
 
+const defaultShutdownTimeout = 30 * time.Second
+
 func (l *listener) Address() string {
 	return l.Addr
 }
+
+func (l *listener) ShutdownTimeout() time.Duration {
+	if l == nil || l.Shutdown <= 0 {
+		return defaultShutdownTimeout
+	}
+	return l.Shutdown
+}
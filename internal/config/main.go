@@ -3,6 +3,7 @@ package config
 import (
 	"database/sql"
 	"fmt"
+	"github.com/bsc-bridge-svc/internal/amount"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/pkg/errors"
@@ -10,6 +11,7 @@ import (
 	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"math/big"
+	"time"
 )
 
 type Config interface {
@@ -18,14 +20,30 @@ type Config interface {
 	Binancer
 	Databaser
 	Odiner
+	Idempotency
+	Sender
+	Reviewer
+	Admin
+	Jobs
+	Breaker
+	Limits
+	Fees
 }
 
 type config struct {
-	Listener *listener  `yaml:"listener"`
-	Log      *logger    `yaml:"log"`
-	Binance  *binancer  `yaml:"binance"`
-	Database *databaser `yaml:"db"`
-	Odin     *odiner    `yaml:"odin"`
+	Listener    *listener    `yaml:"listener"`
+	Log         *logger      `yaml:"log"`
+	Binance     *binancer    `yaml:"binance"`
+	Database    *databaser   `yaml:"db"`
+	Odin        *odiner      `yaml:"odin"`
+	Idempotency *idempotency `yaml:"idempotency"`
+	Sender      *sender      `yaml:"sender"`
+	Review      *reviewer    `yaml:"review"`
+	Admin       *admin       `yaml:"admin"`
+	Jobs        jobs         `yaml:"jobs"`
+	Breaker     *breaker     `yaml:"breaker"`
+	Limits      limits       `yaml:"limits"`
+	Fees        fees         `yaml:"fees"`
 }
 
 func (c config) BinanceApiKey() string {
@@ -40,6 +58,10 @@ func (c config) Address() string {
 	return c.Listener.Address()
 }
 
+func (c config) ShutdownTimeout() time.Duration {
+	return c.Listener.ShutdownTimeout()
+}
+
 func (c config) Logger() *logrus.Logger {
 	return c.Log.Logger()
 }
@@ -52,6 +74,10 @@ func (c config) DB() *sql.DB {
 	return c.Database.DB()
 }
 
+func (c config) DBInMemory() bool {
+	return c.Database.DBInMemory()
+}
+
 func (c config) OdinChainID() string {
 	return c.Odin.OdinChainID()
 }
@@ -72,6 +98,10 @@ func (c config) OdinExchange() (string, int) {
 	return c.Odin.OdinExchange()
 }
 
+func (c config) OdinRounding() amount.Rounding {
+	return c.Odin.OdinRounding()
+}
+
 func (c config) OdinGasPrice() *big.Int {
 	return c.Odin.OdinGasPrice()
 }
@@ -80,6 +110,66 @@ func (c config) OdinGasLimit() *big.Int {
 	return c.Odin.OdinGasLimit()
 }
 
+func (c config) OdinTimeoutBlocks() int64 {
+	return c.Odin.OdinTimeoutBlocks()
+}
+
+func (c config) IdempotencyRetention() time.Duration {
+	return c.Idempotency.IdempotencyRetention()
+}
+
+func (c config) IdempotencyInProgressTTL() time.Duration {
+	return c.Idempotency.IdempotencyInProgressTTL()
+}
+
+func (c config) SenderLease() time.Duration {
+	return c.Sender.SenderLease()
+}
+
+func (c config) SenderBatchSize() uint64 {
+	return c.Sender.SenderBatchSize()
+}
+
+func (c config) Job(name string) Job {
+	return c.Jobs.Job(name)
+}
+
+func (c config) SenderRetry() Retry {
+	return c.Sender.SenderRetry()
+}
+
+func (c config) ReviewThreshold(denom string) (*big.Int, bool) {
+	return c.Review.ReviewThreshold(denom)
+}
+
+func (c config) ReviewFlagged(address string) bool {
+	return c.Review.ReviewFlagged(address)
+}
+
+func (c config) AdminOperator(token string) (Operator, bool) {
+	return c.Admin.AdminOperator(token)
+}
+
+func (c config) BreakerWindow() time.Duration {
+	return c.Breaker.BreakerWindow()
+}
+
+func (c config) BreakerOdinLimits() map[string]Limit {
+	return c.Breaker.BreakerOdinLimits()
+}
+
+func (c config) BreakerBinanceLimits() map[string]Limit {
+	return c.Breaker.BreakerBinanceLimits()
+}
+
+func (c config) AddressLimits(denom string) (binance, odin AddressLimit) {
+	return c.Limits.AddressLimits(denom)
+}
+
+func (c config) Fee(denom string) (FeePolicy, bool) {
+	return c.Fees.Fee(denom)
+}
+
 func New(path string) Config {
 	cfg := config{}
 
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/bsc-bridge-svc/internal/amount"
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -17,9 +18,13 @@ type Odiner interface {
 	OdinMemo() string
 
 	OdinExchange() (string, int)
+	// OdinRounding is the rounding of converted payouts to base units of the exchange denom
+	OdinRounding() amount.Rounding
 
 	OdinGasPrice() *big.Int
 	OdinGasLimit() *big.Int
+	// OdinTimeoutBlocks is the number of blocks the withdrawal transaction may be included in
+	OdinTimeoutBlocks() int64
 }
 
 type odinChainConfig struct {
@@ -28,6 +33,8 @@ type odinChainConfig struct {
 	Memo     string   `yaml:"memo"`
 	GasPrice *big.Int `yaml:"gas_price"`
 	GasLimit *big.Int `yaml:"gas_limit"`
+	// TimeoutBlocks is the number of blocks after which the transaction is not included anymore
+	TimeoutBlocks int64 `yaml:"timeout_blocks"`
 }
 
 type odinSignerConfig struct {
@@ -36,8 +43,9 @@ type odinSignerConfig struct {
 }
 
 type odinExchangeConfig struct {
-	Denom     string `yaml:"denom"`
-	Precision int    `yaml:"precision"`
+	Denom     string          `yaml:"denom"`
+	Precision int             `yaml:"precision"`
+	Rounding  amount.Rounding `yaml:"rounding"`
 }
 
 type odiner struct {
@@ -77,6 +85,13 @@ func (o *odiner) OdinExchange() (string, int) {
 	return o.Exchange.Denom, o.Exchange.Precision
 }
 
+func (o *odiner) OdinRounding() amount.Rounding {
+	if o.Exchange.Rounding == "" {
+		return amount.RoundDown
+	}
+	return o.Exchange.Rounding
+}
+
 func (o *odiner) OdinGasPrice() *big.Int {
 	return o.Chain.GasPrice
 }
@@ -84,3 +99,12 @@ func (o *odiner) OdinGasPrice() *big.Int {
 func (o *odiner) OdinGasLimit() *big.Int {
 	return o.Chain.GasLimit
 }
+
+const defaultOdinTimeoutBlocks = 100
+
+func (o *odiner) OdinTimeoutBlocks() int64 {
+	if o.Chain.TimeoutBlocks <= 0 {
+		return defaultOdinTimeoutBlocks
+	}
+	return o.Chain.TimeoutBlocks
+}
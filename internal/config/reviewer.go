@@ -0,0 +1,42 @@
+package config
+
+import (
+	"math/big"
+	"strings"
+)
+
+type Reviewer interface {
+	// ReviewThreshold is the amount in base units, transfers above which are held for the manual review
+	ReviewThreshold(denom string) (*big.Int, bool)
+	// ReviewFlagged checks whether transfers of the address are held for the manual review
+	ReviewFlagged(address string) bool
+}
+
+type reviewer struct {
+	Thresholds       map[string]*big.Int `yaml:"thresholds"`
+	FlaggedAddresses []string            `yaml:"flagged_addresses"`
+}
+
+func (r *reviewer) ReviewThreshold(denom string) (*big.Int, bool) {
+	if r == nil {
+		return nil, false
+	}
+	threshold, ok := r.Thresholds[denom]
+	if !ok || threshold == nil {
+		return nil, false
+	}
+	return new(big.Int).Set(threshold), true
+}
+
+func (r *reviewer) ReviewFlagged(address string) bool {
+	if r == nil {
+		return false
+	}
+	for _, flagged := range r.FlaggedAddresses {
+		// hex addresses are case insensitive
+		if strings.EqualFold(flagged, address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package config
+
+import "time"
+
+type Sender interface {
+	// SenderLease is how long the claimed transfer is owned by the instance before it may be recovered
+	SenderLease() time.Duration
+	// SenderBatchSize is the maximum number of transfers claimed at once
+	SenderBatchSize() uint64
+	// SenderRetry is the policy of retrying transient failures of the transfer
+	SenderRetry() Retry
+}
+
+type sender struct {
+	Lease     time.Duration `yaml:"lease"`
+	BatchSize uint64        `yaml:"batch_size"`
+	Retry     Retry         `yaml:"retry"`
+}
+
+// Retry defines the exponential backoff, the transfer fails after MaxAttempts
+type Retry struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	Backoff     time.Duration `yaml:"backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+}
+
+const (
+	defaultSenderLease      = 5 * time.Minute
+	defaultSenderBatchSize  = 20
+	defaultRetryMaxAttempts = 5
+	defaultRetryBackoff     = 10 * time.Second
+	defaultRetryMaxBackoff  = 10 * time.Minute
+)
+
+func (s *sender) SenderLease() time.Duration {
+	if s == nil || s.Lease <= 0 {
+		return defaultSenderLease
+	}
+	return s.Lease
+}
+
+func (s *sender) SenderBatchSize() uint64 {
+	if s == nil || s.BatchSize == 0 {
+		return defaultSenderBatchSize
+	}
+	return s.BatchSize
+}
+
+func (s *sender) SenderRetry() Retry {
+	result := Retry{}
+	if s != nil {
+		result = s.Retry
+	}
+
+	if result.MaxAttempts <= 0 {
+		result.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if result.Backoff <= 0 {
+		result.Backoff = defaultRetryBackoff
+	}
+	if result.MaxBackoff < result.Backoff {
+		result.MaxBackoff = defaultRetryMaxBackoff
+		if result.MaxBackoff < result.Backoff {
+			result.MaxBackoff = result.Backoff
+		}
+	}
+	return result
+}
+
+// Delay returns the backoff before the next attempt, doubling it after every attempt made
+func (r Retry) Delay(attempts int) time.Duration {
+	delay := r.Backoff
+	for i := 1; i < attempts && delay < r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxBackoff {
+		return r.MaxBackoff
+	}
+	return delay
+}
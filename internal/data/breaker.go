@@ -0,0 +1,51 @@
+package data
+
+import (
+	"math/big"
+	"time"
+)
+
+// BreakerOutflow pauses payouts once the outflow limits are exceeded
+const BreakerOutflow = "outflow"
+
+// CircuitBreaker stops the operation once tripped, until it is reset by the operator
+type CircuitBreaker struct {
+	Name      string     `db:"name" json:"name"`
+	TrippedAt *time.Time `db:"tripped_at" json:"tripped_at,omitempty"`
+	Reason    *string    `db:"reason" json:"reason,omitempty"`
+	ResetAt   *time.Time `db:"reset_at" json:"reset_at,omitempty"`
+	ResetBy   *string    `db:"reset_by" json:"reset_by,omitempty"`
+}
+
+func (b CircuitBreaker) Tripped() bool {
+	return b.TrippedAt != nil
+}
+
+func (b CircuitBreaker) ToReturn() map[string]interface{} {
+	result := map[string]interface{}{
+		"name":       b.Name,
+		"tripped":    b.Tripped(),
+		"tripped_at": b.TrippedAt,
+		"reason":     b.Reason,
+		"reset_at":   b.ResetAt,
+		"reset_by":   b.ResetBy,
+	}
+
+	return result
+}
+
+// TransferOutflow is the amount paid out by transfers of the binance token in the odin denom
+type TransferOutflow struct {
+	Denom      string   `db:"denom" json:"denom"`
+	Amount     *big.Int `db:"amount" json:"amount"`
+	OdinDenom  string   `db:"odin_denom" json:"odin_denom"`
+	OdinAmount *big.Int `db:"odin_amount" json:"odin_amount"`
+}
+
+// operational pauses toggled by operators, they are stored as breakers, so all replicas see them at once
+const (
+	PauseClaims  = "claims"
+	PausePayouts = "payouts"
+)
+
+var PauseTargets = []string{PauseClaims, PausePayouts}
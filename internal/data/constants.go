@@ -3,8 +3,21 @@ package data
 type Status string
 
 const (
-	StatusSent     Status = "sent"
-	StatusNotSent  Status = "not_sent"
-	StatusFailed   Status = "failed"
-	StatusRefunded Status = "refunded"
+	StatusSent       Status = "sent"
+	StatusNotSent    Status = "not_sent"
+	StatusProcessing Status = "processing"
+	StatusFailed     Status = "failed"
+	StatusRefunded   Status = "refunded"
+	// StatusUnknown is the transfer, which transaction may be included, it is resolved by the chain
+	StatusUnknown Status = "unknown"
+	// StatusOnHold is the transfer waiting for the manual review
+	StatusOnHold Status = "on_hold"
+	// StatusCancelled is the transfer cancelled by the user before it is processed
+	StatusCancelled Status = "cancelled"
 )
+
+// Valid checks whether the status is one of known statuses
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
@@ -0,0 +1,27 @@
+package data
+
+import "time"
+
+// IdempotencyKey stores the response of the request to replay it on retries
+type IdempotencyKey struct {
+	Key         string `db:"key" json:"key"`
+	RequestHash string `db:"request_hash" json:"request_hash"`
+	// Reservation identifies the request holding the key, so a late writer does not affect another request
+	Reservation string `db:"reservation" json:"-"`
+	StatusCode  *int   `db:"status_code" json:"status_code,omitempty"`
+	Response    []byte `db:"response" json:"response,omitempty"`
+	// CommittedAt is set once the changes of the request are committed, so the key is not released
+	CommittedAt *time.Time `db:"committed_at" json:"committed_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt   time.Time  `db:"expires_at" json:"expires_at"`
+}
+
+// Completed checks whether the response of the request is stored
+func (k IdempotencyKey) Completed() bool {
+	return k.StatusCode != nil
+}
+
+// Committed checks whether the changes of the request are committed
+func (k IdempotencyKey) Committed() bool {
+	return k.CommittedAt != nil
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"math/big"
+	"time"
+)
+
+type JournalKind string
+
+const (
+	JournalOpening    JournalKind = "opening"
+	JournalClaim      JournalKind = "claim"
+	JournalRefund     JournalKind = "refund"
+	JournalPayout     JournalKind = "payout"
+	JournalAdjustment JournalKind = "adjustment"
+	JournalCancel     JournalKind = "cancel"
+	JournalFeeSweep   JournalKind = "fee_sweep"
+	// JournalMerge reverses the opening of the duplicate user and moves its activity to the merged one
+	JournalMerge JournalKind = "merge"
+)
+
+type Account string
+
+const (
+	// AccountUser is the balance of the user, the only account projected to users.amount
+	AccountUser Account = "user"
+	// AccountBscHoldings is the source of balances snapshotted from the binance smart chain
+	AccountBscHoldings Account = "bsc_holdings"
+	// AccountPending holds claimed amounts until they are paid out or refunded
+	AccountPending Account = "bridge_pending"
+	// AccountTreasury receives amounts paid out from the odin treasury
+	AccountTreasury Account = "odin_treasury"
+	// AccountAdjustments is the counterpart of manual balance adjustments
+	AccountAdjustments Account = "adjustments"
+	// AccountFees accumulates fees charged from paid out transfers until they are swept
+	AccountFees Account = "bridge_fees"
+	// AccountSweptFees receives fees swept by the operator
+	AccountSweptFees Account = "swept_fees"
+)
+
+// LedgerJournal groups the entries of a single balance movement
+type LedgerJournal struct {
+	ID         int64         `db:"id" json:"id"`
+	Kind       JournalKind   `db:"kind" json:"kind"`
+	UserID     *int64        `db:"user_id" json:"user_id,omitempty"`
+	TransferID *int64        `db:"transfer_id" json:"transfer_id,omitempty"`
+	Memo       string        `db:"memo" json:"memo,omitempty"`
+	CreatedAt  time.Time     `db:"created_at" json:"created_at"`
+	Entries    []LedgerEntry `db:"-" json:"entries"`
+}
+
+type LedgerEntry struct {
+	ID        int64    `db:"id" json:"id"`
+	JournalID int64    `db:"journal_id" json:"journal_id"`
+	Account   Account  `db:"account" json:"account"`
+	UserID    *int64   `db:"user_id" json:"user_id,omitempty"`
+	Denom     string   `db:"denom" json:"denom"`
+	Debit     *big.Int `db:"debit" json:"debit"`
+	Credit    *big.Int `db:"credit" json:"credit"`
+}
+
+// AccountBalance is the sum of credits less debits of the account in the denom
+type AccountBalance struct {
+	Account Account  `db:"account" json:"account"`
+	Denom   string   `db:"denom" json:"denom"`
+	Amount  *big.Int `db:"amount" json:"amount"`
+}
+
+// Balanced checks that debits equal credits for every denom of the journal
+func (j LedgerJournal) Balanced() bool {
+	totals := make(map[string]*big.Int)
+	for _, entry := range j.Entries {
+		total, ok := totals[entry.Denom]
+		if !ok {
+			total = new(big.Int)
+			totals[entry.Denom] = total
+		}
+		total.Add(total, entry.Debit)
+		total.Sub(total, entry.Credit)
+	}
+
+	for _, total := range totals {
+		if total.Sign() != 0 {
+			return false
+		}
+	}
+	return len(j.Entries) > 0
+}
+
+func (j LedgerJournal) ToMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"kind":        j.Kind,
+		"user_id":     j.UserID,
+		"transfer_id": j.TransferID,
+		"memo":        j.Memo,
+	}
+
+	return result
+}
+
+func (e LedgerEntry) ToMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"journal_id": e.JournalID,
+		"account":    e.Account,
+		"user_id":    e.UserID,
+		"denom":      e.Denom,
+		"debit":      e.Debit.String(),
+		"credit":     e.Credit.String(),
+	}
+
+	return result
+}
+
+func (b AccountBalance) ToReturn() map[string]interface{} {
+	result := map[string]interface{}{
+		"account": b.Account,
+		"denom":   b.Denom,
+		"amount":  b.Amount.String(),
+	}
+
+	return result
+}
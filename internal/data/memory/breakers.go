@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"time"
+)
+
+type breakers struct {
+	storage *storage
+}
+
+func (bs *breakers) Get(_ context.Context, name string) (data.CircuitBreaker, error) {
+	result := data.CircuitBreaker{Name: name}
+	err := bs.storage.do(func(st *state) error {
+		if breaker, ok := st.breakers[name]; ok {
+			result = breaker
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (bs *breakers) Trip(_ context.Context, name, reason string) (bool, error) {
+	tripped := false
+	err := bs.storage.do(func(st *state) error {
+		breaker, ok := st.breakers[name]
+		if ok && breaker.Tripped() {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		breaker.Name = name
+		breaker.TrippedAt = &now
+		breaker.Reason = &reason
+		st.breakers[name] = breaker
+		tripped = true
+		return nil
+	})
+	return tripped, err
+}
+
+func (bs *breakers) Reset(_ context.Context, name, operator string) (bool, error) {
+	reset := false
+	err := bs.storage.do(func(st *state) error {
+		breaker, ok := st.breakers[name]
+		if !ok || !breaker.Tripped() {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		breaker.TrippedAt = nil
+		breaker.ResetAt = &now
+		breaker.ResetBy = &operator
+		st.breakers[name] = breaker
+		reset = true
+		return nil
+	})
+	return reset, err
+}
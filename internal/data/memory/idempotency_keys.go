@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type idempotencyKeys struct {
+	storage *storage
+}
+
+func (k *idempotencyKeys) Reserve(_ context.Context, key data.IdempotencyKey) (bool, error) {
+	var reserved bool
+	err := k.storage.do(func(st *state) error {
+		now := time.Now().UTC()
+		if existing, ok := st.keys[key.Key]; ok && existing.ExpiresAt.After(now) {
+			return nil
+		}
+
+		st.keys[key.Key] = data.IdempotencyKey{
+			Key:         key.Key,
+			RequestHash: key.RequestHash,
+			Reservation: key.Reservation,
+			CreatedAt:   now,
+			ExpiresAt:   key.ExpiresAt,
+		}
+		reserved = true
+		return nil
+	})
+	return reserved, err
+}
+
+func (k *idempotencyKeys) Get(_ context.Context, key string) (*data.IdempotencyKey, error) {
+	var result *data.IdempotencyKey
+	err := k.storage.do(func(st *state) error {
+		if existing, ok := st.keys[key]; ok {
+			result = &existing
+		}
+		return nil
+	})
+	return result, err
+}
+
+// reserved returns the key held by the request, which response is not stored yet
+func reserved(st *state, reservation data.IdempotencyKey, action string) (data.IdempotencyKey, error) {
+	existing, ok := st.keys[reservation.Key]
+	if !ok || existing.RequestHash != reservation.RequestHash ||
+		existing.Reservation != reservation.Reservation || existing.Completed() {
+		return existing, errors.Wrapf(postgres.ErrReservationLost, "failed to %s idempotency key", action)
+	}
+	return existing, nil
+}
+
+func (k *idempotencyKeys) Commit(_ context.Context, reservation data.IdempotencyKey, expiresAt time.Time) error {
+	return k.storage.do(func(st *state) error {
+		existing, err := reserved(st, reservation, "commit")
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if !existing.ExpiresAt.After(now) {
+			return errors.Wrap(postgres.ErrReservationLost, "failed to commit idempotency key")
+		}
+		existing.CommittedAt = &now
+		existing.ExpiresAt = expiresAt
+		st.keys[existing.Key] = existing
+		return nil
+	})
+}
+
+func (k *idempotencyKeys) Complete(
+	_ context.Context,
+	reservation data.IdempotencyKey,
+	statusCode int,
+	response []byte,
+	expiresAt time.Time,
+) error {
+	return k.storage.do(func(st *state) error {
+		existing, err := reserved(st, reservation, "complete")
+		if err != nil {
+			return err
+		}
+		existing.StatusCode = &statusCode
+		existing.Response = append([]byte(nil), response...)
+		existing.ExpiresAt = expiresAt
+		st.keys[existing.Key] = existing
+		return nil
+	})
+}
+
+func (k *idempotencyKeys) Release(_ context.Context, reservation data.IdempotencyKey) error {
+	return k.storage.do(func(st *state) error {
+		existing, err := reserved(st, reservation, "release")
+		if err != nil {
+			return err
+		}
+		if existing.Committed() {
+			return errors.Wrap(postgres.ErrReservationLost, "failed to release idempotency key")
+		}
+		delete(st.keys, existing.Key)
+		return nil
+	})
+}
+
+func (k *idempotencyKeys) DeleteExpired(_ context.Context) (int64, error) {
+	var deleted int64
+	err := k.storage.do(func(st *state) error {
+		now := time.Now().UTC()
+		for key, existing := range st.keys {
+			if !existing.ExpiresAt.After(now) {
+				delete(st.keys, key)
+				deleted++
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
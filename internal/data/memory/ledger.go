@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/pkg/errors"
+	"math/big"
+	"sort"
+	"time"
+)
+
+type ledger struct {
+	storage *storage
+}
+
+func (l *ledger) CreateJournal(_ context.Context, journal data.LedgerJournal) (int64, error) {
+	if !journal.Balanced() {
+		return 0, errors.Errorf("journal %s is not balanced", journal.Kind)
+	}
+
+	var id int64
+	err := l.storage.do(func(st *state) error {
+		st.lastJournalID++
+		journal.ID = st.lastJournalID
+		journal.CreatedAt = time.Now().UTC()
+
+		entries := make([]data.LedgerEntry, 0, len(journal.Entries))
+		for _, entry := range journal.Entries {
+			st.lastEntryID++
+			entry.ID = st.lastEntryID
+			entry.JournalID = journal.ID
+			entries = append(entries, entry)
+		}
+		journal.Entries = entries
+
+		st.journals = append(st.journals, journal)
+		st.entries = append(st.entries, entries...)
+		id = journal.ID
+		return nil
+	})
+	return id, err
+}
+
+func (l *ledger) SelectEntries(_ context.Context, account data.Account, userID int64) ([]data.LedgerEntry, error) {
+	result := make([]data.LedgerEntry, 0)
+	err := l.storage.do(func(st *state) error {
+		for _, entry := range st.entries {
+			if entry.Account == account && entry.UserID != nil && *entry.UserID == userID {
+				result = append(result, entry)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (l *ledger) RebuildBalances(_ context.Context) (int64, error) {
+	var changed int64
+	err := l.storage.do(func(st *state) error {
+		balances := make(map[int64]*big.Int)
+		for _, entry := range st.entries {
+			if entry.Account != data.AccountUser || entry.UserID == nil {
+				continue
+			}
+			balance, ok := balances[*entry.UserID]
+			if !ok {
+				balance = new(big.Int)
+				balances[*entry.UserID] = balance
+			}
+			balance.Add(balance, entry.Credit)
+			balance.Sub(balance, entry.Debit)
+		}
+
+		for id, user := range st.users {
+			balance, ok := balances[id]
+			if !ok {
+				balance = new(big.Int)
+			}
+			if user.Amount != nil && user.Amount.Cmp(balance) == 0 {
+				continue
+			}
+			user.Amount = balance
+			st.users[id] = user
+			changed++
+		}
+		return nil
+	})
+	return changed, err
+}
+
+func (l *ledger) SelectBalances(_ context.Context, account data.Account) ([]data.AccountBalance, error) {
+	result := make([]data.AccountBalance, 0)
+	err := l.storage.do(func(st *state) error {
+		balances := make(map[string]*big.Int)
+		for _, entry := range st.entries {
+			if entry.Account != account {
+				continue
+			}
+			balance, ok := balances[entry.Denom]
+			if !ok {
+				balance = new(big.Int)
+				balances[entry.Denom] = balance
+			}
+			balance.Add(balance, entry.Credit)
+			balance.Sub(balance, entry.Debit)
+		}
+
+		for denom, balance := range balances {
+			result = append(result, data.AccountBalance{Account: account, Denom: denom, Amount: balance})
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Denom < result[j].Denom
+		})
+		return nil
+	})
+	return result, err
+}
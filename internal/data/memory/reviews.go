@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type reviews struct {
+	storage *storage
+}
+
+func (rs *reviews) CreateReview(_ context.Context, review data.TransferReview) (int64, error) {
+	var id int64
+	err := rs.storage.do(func(st *state) error {
+		if _, ok := st.transfers[review.TransferID]; !ok {
+			return errors.Errorf("failed to insert transfer review: transfer not found: %d", review.TransferID)
+		}
+
+		st.lastReviewID++
+		review.ID = st.lastReviewID
+		review.CreatedAt = time.Now().UTC()
+		st.reviews = append(st.reviews, review)
+		id = review.ID
+		return nil
+	})
+	return id, err
+}
+
+func (rs *reviews) SelectReviews(_ context.Context, transferID int64) ([]data.TransferReview, error) {
+	result := make([]data.TransferReview, 0)
+	err := rs.storage.do(func(st *state) error {
+		for _, review := range st.reviews {
+			if review.TransferID == transferID {
+				result = append(result, review)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
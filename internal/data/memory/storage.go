@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"sync"
+	"time"
+)
+
+// state holds all the tables, rows are stored by value and never mutated in place
+type state struct {
+	users     map[int64]data.User
+	transfers map[int64]data.Transfer
+	events    []data.TransferEvent
+	reviews   []data.TransferReview
+	journals  []data.LedgerJournal
+	entries   []data.LedgerEntry
+	keys      map[string]data.IdempotencyKey
+	breakers  map[string]data.CircuitBreaker
+
+	lastUserID     int64
+	lastTransferID int64
+	lastEventID    int64
+	lastReviewID   int64
+	lastJournalID  int64
+	lastEntryID    int64
+}
+
+func newState() *state {
+	return &state{
+		users:     make(map[int64]data.User),
+		transfers: make(map[int64]data.Transfer),
+		keys:      make(map[string]data.IdempotencyKey),
+		breakers:  make(map[string]data.CircuitBreaker),
+	}
+}
+
+func (s *state) clone() *state {
+	result := *s
+	result.users = make(map[int64]data.User, len(s.users))
+	for id, user := range s.users {
+		result.users[id] = user
+	}
+	result.transfers = make(map[int64]data.Transfer, len(s.transfers))
+	for id, transfer := range s.transfers {
+		result.transfers[id] = transfer
+	}
+	result.keys = make(map[string]data.IdempotencyKey, len(s.keys))
+	for key, value := range s.keys {
+		result.keys[key] = value
+	}
+	result.breakers = make(map[string]data.CircuitBreaker, len(s.breakers))
+	for name, breaker := range s.breakers {
+		result.breakers[name] = breaker
+	}
+	result.events = append([]data.TransferEvent(nil), s.events...)
+	result.reviews = append([]data.TransferReview(nil), s.reviews...)
+	result.journals = append([]data.LedgerJournal(nil), s.journals...)
+	result.entries = append([]data.LedgerEntry(nil), s.entries...)
+	return &result
+}
+
+func (s *state) addEvent(transferID int64, from, to data.Status, reason string, createdAt time.Time) {
+	s.lastEventID++
+	s.events = append(s.events, data.TransferEvent{
+		ID:         s.lastEventID,
+		TransferID: transferID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		CreatedAt:  createdAt,
+	})
+}
+
+// database is shared by all repositories of the storage
+type database struct {
+	mu    sync.Mutex
+	state *state
+}
+
+// storage is the in-memory implementation of postgres.Storage, mostly used for tests.
+// Transactions are serializable: they hold the lock and work on a copy of the state,
+// which replaces the original on success.
+type storage struct {
+	db *database
+	tx *state
+}
+
+func NewStorage() postgres.Storage {
+	return &storage{
+		db: &database{state: newState()},
+	}
+}
+
+// do runs fn with exclusive access to the current state
+func (s *storage) do(fn func(*state) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return fn(s.db.state)
+}
+
+func (s *storage) Users() postgres.Users {
+	return &users{storage: s}
+}
+
+func (s *storage) Transfers() postgres.Transfers {
+	return &transfers{storage: s}
+}
+
+func (s *storage) Ledger() postgres.Ledger {
+	return &ledger{storage: s}
+}
+
+func (s *storage) IdempotencyKeys() postgres.IdempotencyKeys {
+	return &idempotencyKeys{storage: s}
+}
+
+func (s *storage) Reviews() postgres.Reviews {
+	return &reviews{storage: s}
+}
+
+func (s *storage) Breakers() postgres.Breakers {
+	return &breakers{storage: s}
+}
+
+// Lock does nothing but checking the transaction, as transactions are already serialized
+func (s *storage) Lock(_ context.Context, _ ...string) error {
+	if s.tx == nil {
+		return postgres.ErrNotInTransaction
+	}
+	return nil
+}
+
+func (s *storage) Transaction(ctx context.Context, fn func(postgres.Storage) error) error {
+	// already in transaction
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx := &storage{db: s.db, tx: s.db.state.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.db.state = tx.tx
+	return nil
+}
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/pkg/errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestUsers_CreateUser(t *testing.T) {
+	ctx := context.Background()
+	users := NewStorage().Users()
+
+	id, err := users.CreateUser(ctx, data.User{Address: "0x1", Amount: big.NewInt(10), Denom: "odin"})
+	if err != nil {
+		t.Fatalf("failed to create user: %s", err)
+	}
+
+	if _, err := users.CreateUser(ctx, data.User{Address: "0x1", Amount: big.NewInt(5), Denom: "odin"}); err == nil {
+		t.Fatal("expected duplicate address and denom to be rejected")
+	}
+	if _, err := users.CreateUser(ctx, data.User{Address: "0x1", Amount: big.NewInt(5), Denom: "geo"}); err != nil {
+		t.Fatalf("failed to create user with another denom: %s", err)
+	}
+
+	user, err := users.GetUser(ctx, "0x1", "odin")
+	if err != nil {
+		t.Fatalf("failed to get user: %s", err)
+	}
+	if user == nil || user.ID != id || user.Amount.Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// returned amounts must not share memory with the stored ones
+	user.Amount.SetInt64(0)
+	user, _ = users.GetUserById(ctx, id)
+	if user.Amount.Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("stored amount was mutated: %s", user.Amount)
+	}
+}
+
+func TestUsers_AddAmount(t *testing.T) {
+	ctx := context.Background()
+	users := NewStorage().Users()
+
+	id, _ := users.CreateUser(ctx, data.User{Address: "0x1", Amount: big.NewInt(10), Denom: "odin"})
+	if err := users.AddAmount(ctx, id, big.NewInt(-4)); err != nil {
+		t.Fatalf("failed to add amount: %s", err)
+	}
+	if err := users.AddAmount(ctx, id, big.NewInt(-7)); err == nil {
+		t.Fatal("expected negative balance to be rejected")
+	}
+
+	user, _ := users.GetUserById(ctx, id)
+	if user.Amount.Cmp(big.NewInt(6)) != 0 {
+		t.Fatalf("expected amount 6, got %s", user.Amount)
+	}
+}
+
+func TestStorage_Transaction(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+
+	err := storage.Transaction(ctx, func(tx postgres.Storage) error {
+		if _, err := tx.Users().CreateUser(ctx, data.User{Address: "0x1", Amount: big.NewInt(1), Denom: "odin"}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	if err == nil {
+		t.Fatal("expected transaction error")
+	}
+
+	user, _ := storage.Users().GetUser(ctx, "0x1", "odin")
+	if user != nil {
+		t.Fatalf("expected user to be rolled back, got %+v", user)
+	}
+
+	err = storage.Transaction(ctx, func(tx postgres.Storage) error {
+		_, err := tx.Users().CreateUser(ctx, data.User{Address: "0x1", Amount: big.NewInt(1), Denom: "odin"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to commit transaction: %s", err)
+	}
+
+	user, _ = storage.Users().GetUser(ctx, "0x1", "odin")
+	if user == nil {
+		t.Fatal("expected user to be committed")
+	}
+}
+
+func TestTransfers_Filters(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+
+	first, _ := storage.Users().CreateUser(ctx, data.User{Address: "0x1", Amount: new(big.Int), Denom: "odin"})
+	second, _ := storage.Users().CreateUser(ctx, data.User{Address: "0x2", Amount: new(big.Int), Denom: "odin"})
+	for i := 0; i < 5; i++ {
+		userID, status := first, data.StatusNotSent
+		if i%2 == 1 {
+			userID, status = second, data.StatusSent
+		}
+		_, err := storage.Transfers().CreateTransfer(ctx, data.Transfer{
+			Address: "odin1",
+			Amount:  big.NewInt(int64(i + 1)),
+			Denom:   "odin",
+			Status:  status,
+			UserID:  userID,
+		})
+		if err != nil {
+			t.Fatalf("failed to create transfer: %s", err)
+		}
+	}
+
+	transfers := storage.Transfers()
+	notSent, _ := transfers.FilterByStatus(data.StatusNotSent).Select(ctx)
+	if len(notSent) != 3 {
+		t.Fatalf("expected 3 not sent transfers, got %d", len(notSent))
+	}
+
+	byUser, _ := transfers.FilterByUserAddress("0x2").Select(ctx)
+	if len(byUser) != 2 {
+		t.Fatalf("expected 2 transfers of the second user, got %d", len(byUser))
+	}
+
+	all, _ := transfers.Select(ctx)
+	if len(all) != 5 {
+		t.Fatalf("filters must not affect the origin repository, got %d transfers", len(all))
+	}
+
+	page, _ := transfers.Page(data.PageParams{Limit: 2, Order: data.OrderDesc}).Select(ctx)
+	if len(page) != 2 || page[0].ID != 5 || page[1].ID != 4 {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+
+	cursor := page[1].ID
+	page, _ = transfers.Page(data.PageParams{Cursor: &cursor, Limit: 2, Order: data.OrderDesc}).Select(ctx)
+	if len(page) != 2 || page[0].ID != 3 || page[1].ID != 2 {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+}
+
+func TestTransfers_Transit(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+
+	userID, _ := storage.Users().CreateUser(ctx, data.User{Address: "0x1", Amount: new(big.Int), Denom: "odin"})
+	transfer := data.Transfer{Address: "odin1", Amount: big.NewInt(1), Denom: "odin", Status: data.StatusNotSent, UserID: userID}
+	transfer.ID, _ = storage.Transfers().CreateTransfer(ctx, transfer)
+
+	err := storage.Transfers().Transit(ctx, transfer, data.StatusRefunded, "")
+	if !errors.Is(err, data.ErrTransitionNotAllowed) {
+		t.Fatalf("expected transition to be rejected, got %v", err)
+	}
+
+	if err := storage.Transfers().Transit(ctx, transfer, data.StatusProcessing, "claimed"); err != nil {
+		t.Fatalf("failed to transit transfer: %s", err)
+	}
+
+	// the same transition from the stale status must conflict
+	err = storage.Transfers().Transit(ctx, transfer, data.StatusProcessing, "claimed")
+	if !errors.Is(err, postgres.ErrStatusConflict) {
+		t.Fatalf("expected status conflict, got %v", err)
+	}
+
+	events, _ := storage.Transfers().SelectEvents(ctx, transfer.ID)
+	if len(events) != 1 || events[0].FromStatus != data.StatusNotSent || events[0].ToStatus != data.StatusProcessing {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestTransfers_Claim(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+
+	userID, _ := storage.Users().CreateUser(ctx, data.User{Address: "0x1", Amount: new(big.Int), Denom: "odin"})
+	for i := 0; i < 3; i++ {
+		transfer := data.Transfer{Address: "odin1", Amount: big.NewInt(1), Denom: "odin", Status: data.StatusNotSent, UserID: userID}
+		if _, err := storage.Transfers().CreateTransfer(ctx, transfer); err != nil {
+			t.Fatalf("failed to create transfer: %s", err)
+		}
+	}
+
+	stale, _ := storage.Transfers().Claim(ctx, "first", -time.Second, 2)
+	if len(stale) != 2 || stale[0].ID != 1 || *stale[0].LeaseOwner != "first" {
+		t.Fatalf("unexpected claimed transfers: %+v", stale)
+	}
+
+	other, _ := storage.Transfers().Claim(ctx, "second", time.Minute, 10)
+	if len(other) != 1 || other[0].ID != 3 {
+		t.Fatalf("claimed transfers must be skipped, got %+v", other)
+	}
+
+	recovered, _ := storage.Transfers().RecoverExpired(ctx)
+	if recovered != 2 {
+		t.Fatalf("expected 2 recovered transfers, got %d", recovered)
+	}
+
+	reclaimed, _ := storage.Transfers().Claim(ctx, "second", time.Minute, 10)
+	if len(reclaimed) != 2 {
+		t.Fatalf("expected recovered transfers to be claimed again, got %+v", reclaimed)
+	}
+
+	// the previous owner must neither move nor update the transfer leased to another instance
+	err := storage.Transfers().Transit(ctx, stale[0], data.StatusFailed, "")
+	if !errors.Is(err, postgres.ErrStatusConflict) {
+		t.Fatalf("expected stale lease to conflict, got %v", err)
+	}
+	hash := "stale"
+	stale[0].OdinTxHash = &hash
+	if err := storage.Transfers().UpdateTransfer(ctx, stale[0]); !errors.Is(err, postgres.ErrStatusConflict) {
+		t.Fatalf("expected update by stale lease to conflict, got %v", err)
+	}
+	reclaimed[0].OdinTxHash = &hash
+	if err := storage.Transfers().UpdateTransfer(ctx, reclaimed[0]); err != nil {
+		t.Fatalf("failed to update by the lease owner: %s", err)
+	}
+	if err := storage.Transfers().Transit(ctx, reclaimed[0], data.StatusFailed, ""); err != nil {
+		t.Fatalf("failed to transit by the lease owner: %s", err)
+	}
+}
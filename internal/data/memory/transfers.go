@@ -0,0 +1,373 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/pkg/errors"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// transfers is immutable, filters return a copy of the repository like the postgres implementation does
+type transfers struct {
+	storage *storage
+	filters []func(*state, data.Transfer) bool
+	page    *data.PageParams
+}
+
+func (t *transfers) New() postgres.Transfers {
+	return &transfers{storage: t.storage}
+}
+
+func (t *transfers) where(filter func(*state, data.Transfer) bool) *transfers {
+	filters := make([]func(*state, data.Transfer) bool, 0, len(t.filters)+1)
+	filters = append(filters, t.filters...)
+	return &transfers{
+		storage: t.storage,
+		filters: append(filters, filter),
+		page:    t.page,
+	}
+}
+
+func (t *transfers) FilterByStatus(statuses ...data.Status) postgres.Transfers {
+	return t.where(func(_ *state, transfer data.Transfer) bool {
+		for _, status := range statuses {
+			if transfer.Status == status {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (t *transfers) FilterByDenom(denoms ...string) postgres.Transfers {
+	return t.where(func(_ *state, transfer data.Transfer) bool {
+		for _, denom := range denoms {
+			if transfer.Denom == denom {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (t *transfers) FilterByAddress(address string) postgres.Transfers {
+	return t.where(func(_ *state, transfer data.Transfer) bool {
+		return transfer.Address == address
+	})
+}
+
+func (t *transfers) FilterByUserAddress(address string) postgres.Transfers {
+	return t.where(func(st *state, transfer data.Transfer) bool {
+		user, ok := st.users[transfer.UserID]
+		return ok && user.Address == address
+	})
+}
+
+func (t *transfers) FilterByCreatedAt(from, to *time.Time) postgres.Transfers {
+	return t.where(func(_ *state, transfer data.Transfer) bool {
+		if from != nil && transfer.CreatedAt.Before(*from) {
+			return false
+		}
+		if to != nil && !transfer.CreatedAt.Before(*to) {
+			return false
+		}
+		return true
+	})
+}
+
+func (t *transfers) Page(params data.PageParams) postgres.Transfers {
+	result := t
+	if params.Cursor != nil {
+		cursor := *params.Cursor
+		result = result.where(func(_ *state, transfer data.Transfer) bool {
+			if params.Order == data.OrderAsc {
+				return transfer.ID > cursor
+			}
+			return transfer.ID < cursor
+		})
+	}
+	return &transfers{
+		storage: t.storage,
+		filters: result.filters,
+		page:    &params,
+	}
+}
+
+func (t *transfers) Select(_ context.Context) ([]data.Transfer, error) {
+	var result []data.Transfer
+	err := t.storage.do(func(st *state) error {
+		for _, transfer := range st.transfers {
+			if t.match(st, transfer) {
+				transfer.Amount = copyAmount(transfer.Amount)
+				transfer.OdinAmount = copyOptionalAmount(transfer.OdinAmount)
+				transfer.Fee = copyOptionalAmount(transfer.Fee)
+				transfer.OdinDust = copyOptionalDecimal(transfer.OdinDust)
+				result = append(result, transfer)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	desc := t.page != nil && t.page.Order != data.OrderAsc
+	sort.Slice(result, func(i, j int) bool {
+		if desc {
+			return result[i].ID > result[j].ID
+		}
+		return result[i].ID < result[j].ID
+	})
+	if t.page != nil && uint64(len(result)) > t.page.Limit {
+		result = result[:t.page.Limit]
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func (t *transfers) match(st *state, transfer data.Transfer) bool {
+	for _, filter := range t.filters {
+		if !filter(st, transfer) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *transfers) SelectStatus(ctx context.Context, status data.Status) ([]data.Transfer, error) {
+	return t.FilterByStatus(status).Select(ctx)
+}
+
+func (t *transfers) Get(ctx context.Context, id int64) (*data.Transfer, error) {
+	result, err := t.where(func(_ *state, transfer data.Transfer) bool {
+		return transfer.ID == id
+	}).Select(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return &result[0], nil
+}
+
+func (t *transfers) CreateTransfer(_ context.Context, transfer data.Transfer) (int64, error) {
+	var id int64
+	err := t.storage.do(func(st *state) error {
+		if _, ok := st.users[transfer.UserID]; !ok {
+			return errors.Errorf("failed to insert transfer: user not found: %d", transfer.UserID)
+		}
+		if transfer.Amount != nil && transfer.Amount.Sign() < 0 {
+			return errors.New("failed to insert transfer: negative amount")
+		}
+
+		now := time.Now().UTC()
+		st.lastTransferID++
+		transfer.ID = st.lastTransferID
+		transfer.Amount = copyAmount(transfer.Amount)
+		transfer.OdinAmount = copyOptionalAmount(transfer.OdinAmount)
+		transfer.Fee = copyOptionalAmount(transfer.Fee)
+		transfer.OdinDust = copyOptionalDecimal(transfer.OdinDust)
+		transfer.CreatedAt = now
+		transfer.UpdatedAt = now
+		if transfer.Status == "" {
+			transfer.Status = data.StatusNotSent
+		}
+		st.transfers[transfer.ID] = transfer
+		id = transfer.ID
+		return nil
+	})
+	return id, err
+}
+
+func (t *transfers) UpdateTransfer(_ context.Context, transfer data.Transfer) error {
+	return t.storage.do(func(st *state) error {
+		existing, ok := st.transfers[transfer.ID]
+		if !ok || existing.Status != transfer.Status || !ownsLease(existing, transfer) || leaseExpired(existing, time.Now().UTC()) {
+			return errors.Wrapf(postgres.ErrStatusConflict, "transfer %d is not %s anymore or its lease is expired", transfer.ID, transfer.Status)
+		}
+
+		transfer.Amount = copyAmount(transfer.Amount)
+		transfer.OdinAmount = copyOptionalAmount(transfer.OdinAmount)
+		transfer.Fee = copyOptionalAmount(transfer.Fee)
+		transfer.OdinDust = copyOptionalDecimal(transfer.OdinDust)
+		transfer.Status = existing.Status
+		transfer.LeaseOwner = existing.LeaseOwner
+		transfer.LeaseExpiresAt = existing.LeaseExpiresAt
+		transfer.CreatedAt = existing.CreatedAt
+		transfer.UpdatedAt = time.Now().UTC()
+		st.transfers[transfer.ID] = transfer
+		return nil
+	})
+}
+
+func (t *transfers) Transit(_ context.Context, transfer data.Transfer, to data.Status, reason string) error {
+	if !data.CanTransit(transfer.Status, to) {
+		return errors.Wrapf(data.ErrTransitionNotAllowed, "from %s to %s", transfer.Status, to)
+	}
+
+	return t.storage.do(func(st *state) error {
+		existing, ok := st.transfers[transfer.ID]
+		if !ok || existing.Status != transfer.Status || !ownsLease(existing, transfer) {
+			return errors.Wrapf(postgres.ErrStatusConflict, "transfer %d is not %s anymore", transfer.ID, transfer.Status)
+		}
+
+		now := time.Now().UTC()
+		from := transfer.Status
+		transfer.Amount = copyAmount(transfer.Amount)
+		transfer.OdinAmount = copyOptionalAmount(transfer.OdinAmount)
+		transfer.Fee = copyOptionalAmount(transfer.Fee)
+		transfer.OdinDust = copyOptionalDecimal(transfer.OdinDust)
+		transfer.Status = to
+		if to != data.StatusProcessing {
+			transfer.LeaseOwner = nil
+			transfer.LeaseExpiresAt = nil
+		}
+		transfer.CreatedAt = existing.CreatedAt
+		transfer.UpdatedAt = now
+		st.transfers[transfer.ID] = transfer
+
+		st.addEvent(transfer.ID, from, to, reason, now)
+		return nil
+	})
+}
+
+// ownsLease checks that only the owner of the lease moves the transfer out of processing
+func ownsLease(existing, transfer data.Transfer) bool {
+	if transfer.Status != data.StatusProcessing || transfer.LeaseOwner == nil {
+		return true
+	}
+	return existing.LeaseOwner != nil && *existing.LeaseOwner == *transfer.LeaseOwner
+}
+
+// leaseExpired checks whether the processing transfer may be already recovered and claimed by another instance
+func leaseExpired(existing data.Transfer, now time.Time) bool {
+	return existing.Status == data.StatusProcessing && existing.LeaseExpiresAt != nil && !existing.LeaseExpiresAt.After(now)
+}
+
+func (t *transfers) SelectEvents(_ context.Context, transferID int64) ([]data.TransferEvent, error) {
+	result := make([]data.TransferEvent, 0)
+	err := t.storage.do(func(st *state) error {
+		for _, event := range st.events {
+			if event.TransferID == transferID {
+				result = append(result, event)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (t *transfers) Claim(_ context.Context, owner string, lease time.Duration, limit uint64) ([]data.Transfer, error) {
+	result := make([]data.Transfer, 0)
+	err := t.storage.do(func(st *state) error {
+		now := time.Now().UTC()
+		ids := make([]int64, 0)
+		for id, transfer := range st.transfers {
+			due := transfer.NextAttemptAt == nil || !transfer.NextAttemptAt.After(now)
+			if transfer.Status == data.StatusNotSent && due {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if uint64(len(ids)) > limit {
+			ids = ids[:limit]
+		}
+
+		expiresAt := now.Add(lease)
+		for _, id := range ids {
+			transfer := st.transfers[id]
+			transfer.Status = data.StatusProcessing
+			transfer.LeaseOwner = &owner
+			transfer.LeaseExpiresAt = &expiresAt
+			transfer.UpdatedAt = now
+			st.transfers[id] = transfer
+			st.addEvent(id, data.StatusNotSent, data.StatusProcessing, "claimed by "+owner, now)
+
+			transfer.Amount = copyAmount(transfer.Amount)
+			transfer.OdinAmount = copyOptionalAmount(transfer.OdinAmount)
+			transfer.Fee = copyOptionalAmount(transfer.Fee)
+			transfer.OdinDust = copyOptionalDecimal(transfer.OdinDust)
+			result = append(result, transfer)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (t *transfers) RecoverExpired(_ context.Context) (int64, error) {
+	var recovered int64
+	err := t.storage.do(func(st *state) error {
+		now := time.Now().UTC()
+		for id, transfer := range st.transfers {
+			if transfer.Status != data.StatusProcessing || transfer.LeaseExpiresAt == nil || !transfer.LeaseExpiresAt.Before(now) {
+				continue
+			}
+
+			transfer.Status = data.StatusNotSent
+			if transfer.OdinTxHash != nil {
+				transfer.Status = data.StatusUnknown
+			}
+			transfer.LeaseOwner = nil
+			transfer.LeaseExpiresAt = nil
+			transfer.UpdatedAt = now
+			st.transfers[id] = transfer
+			st.addEvent(id, data.StatusProcessing, transfer.Status, "lease expired", now)
+			recovered++
+		}
+		return nil
+	})
+	return recovered, err
+}
+
+func (t *transfers) SelectOutflow(_ context.Context, since time.Time) ([]data.TransferOutflow, error) {
+	type group struct{ denom, odinDenom string }
+	sums := make(map[group]*data.TransferOutflow)
+	err := t.storage.do(func(st *state) error {
+		for _, transfer := range st.transfers {
+			if transfer.OdinAmount == nil || transfer.OdinDenom == nil {
+				continue
+			}
+			switch transfer.Status {
+			case data.StatusProcessing, data.StatusUnknown:
+			case data.StatusSent:
+				if transfer.SentAt == nil || transfer.SentAt.Before(since) {
+					continue
+				}
+			default:
+				continue
+			}
+
+			key := group{denom: transfer.Denom, odinDenom: *transfer.OdinDenom}
+			sum, ok := sums[key]
+			if !ok {
+				sum = &data.TransferOutflow{Denom: key.denom, Amount: new(big.Int), OdinDenom: key.odinDenom, OdinAmount: new(big.Int)}
+				sums[key] = sum
+			}
+			sum.Amount.Add(sum.Amount, transfer.Amount)
+			sum.OdinAmount.Add(sum.OdinAmount, transfer.OdinAmount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]data.TransferOutflow, 0, len(sums))
+	for _, sum := range sums {
+		result = append(result, *sum)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Denom != result[j].Denom {
+			return result[i].Denom < result[j].Denom
+		}
+		return result[i].OdinDenom < result[j].OdinDenom
+	})
+	return result, nil
+}
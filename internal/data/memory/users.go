@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/pkg/errors"
+	"math/big"
+	"sort"
+)
+
+type users struct {
+	storage *storage
+}
+
+func (us *users) New() postgres.Users {
+	return &users{storage: us.storage}
+}
+
+// Get returns the user with the lowest id like the unfiltered select does
+func (us *users) Get(_ context.Context) (*data.User, error) {
+	return us.find(func(data.User) bool { return true })
+}
+
+func (us *users) GetUser(_ context.Context, address, denom string) (*data.User, error) {
+	return us.find(func(user data.User) bool {
+		return user.Address == address && user.Denom == denom && user.MergedInto == nil
+	})
+}
+
+func (us *users) GetUserById(_ context.Context, id int64) (*data.User, error) {
+	return us.find(func(user data.User) bool {
+		return user.ID == id
+	})
+}
+
+// GetUserForUpdate does not lock anything, as transactions are already serialized
+func (us *users) GetUserForUpdate(ctx context.Context, id int64) (*data.User, error) {
+	return us.GetUserById(ctx, id)
+}
+
+func (us *users) find(match func(data.User) bool) (*data.User, error) {
+	var result *data.User
+	err := us.storage.do(func(st *state) error {
+		ids := make([]int64, 0, len(st.users))
+		for id := range st.users {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			if user := st.users[id]; match(user) {
+				user.Amount = copyAmount(user.Amount)
+				user.OdinDust = copyDecimal(user.OdinDust)
+				user.OverClaimed = copyOptionalAmount(user.OverClaimed)
+				result = &user
+				return nil
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (us *users) CreateUser(_ context.Context, user data.User) (int64, error) {
+	var id int64
+	err := us.storage.do(func(st *state) error {
+		for _, existing := range st.users {
+			if existing.Address == user.Address && existing.Denom == user.Denom {
+				return errors.Errorf("failed to insert user: duplicate address %s and denom %s", user.Address, user.Denom)
+			}
+		}
+		if user.Amount != nil && user.Amount.Sign() < 0 {
+			return errors.New("failed to insert user: negative amount")
+		}
+
+		st.lastUserID++
+		user.ID = st.lastUserID
+		user.Amount = copyAmount(user.Amount)
+		user.OdinDust = new(big.Rat)
+		st.users[user.ID] = user
+		id = user.ID
+		return nil
+	})
+	return id, err
+}
+
+// UpdateUser updates all the users with the address like the postgres implementation does
+func (us *users) UpdateUser(_ context.Context, user data.User) error {
+	return us.storage.do(func(st *state) error {
+		if user.Amount != nil && user.Amount.Sign() < 0 {
+			return errors.New("failed to update user data: negative amount")
+		}
+		for id, existing := range st.users {
+			if existing.Address != user.Address {
+				continue
+			}
+			existing.Amount = copyAmount(user.Amount)
+			existing.Denom = user.Denom
+			st.users[id] = existing
+		}
+		return nil
+	})
+}
+
+func (us *users) AddAmount(_ context.Context, id int64, delta *big.Int) error {
+	return us.storage.do(func(st *state) error {
+		user, ok := st.users[id]
+		if !ok {
+			return nil
+		}
+
+		amount := new(big.Int).Add(copyAmount(user.Amount), delta)
+		if amount.Sign() < 0 {
+			return errors.New("failed to update user amount: negative amount")
+		}
+		user.Amount = amount
+		st.users[id] = user
+		return nil
+	})
+}
+
+func (us *users) AddOdinDust(_ context.Context, id int64, dust *big.Rat) error {
+	return us.storage.do(func(st *state) error {
+		user, ok := st.users[id]
+		if !ok {
+			return nil
+		}
+		user.OdinDust = new(big.Rat).Add(copyDecimal(user.OdinDust), dust)
+		st.users[id] = user
+		return nil
+	})
+}
+
+func (us *users) DeleteUser(_ context.Context, address string) error {
+	return us.storage.do(func(st *state) error {
+		for id, user := range st.users {
+			if user.Address == address {
+				delete(st.users, id)
+			}
+		}
+		return nil
+	})
+}
+
+// copyAmount prevents callers from mutating the stored amounts
+func copyAmount(amount *big.Int) *big.Int {
+	if amount == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(amount)
+}
+
+// copyOptionalAmount copies the amount keeping it nil if it is not set
+func copyOptionalAmount(amount *big.Int) *big.Int {
+	if amount == nil {
+		return nil
+	}
+	return new(big.Int).Set(amount)
+}
+
+// copyDecimal prevents callers from mutating the stored values
+func copyDecimal(value *big.Rat) *big.Rat {
+	if value == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(value)
+}
+
+// copyOptionalDecimal copies the value keeping it nil if it is not set
+func copyOptionalDecimal(value *big.Rat) *big.Rat {
+	if value == nil {
+		return nil
+	}
+	return new(big.Rat).Set(value)
+}
@@ -0,0 +1,15 @@
+package data
+
+type Order string
+
+const (
+	OrderAsc  Order = "asc"
+	OrderDesc Order = "desc"
+)
+
+// PageParams defines keyset pagination, cursor is the id of the last record on the previous page
+type PageParams struct {
+	Cursor *int64
+	Limit  uint64
+	Order  Order
+}
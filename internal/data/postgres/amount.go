@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+// amount scans numeric(78,0) columns directly into big.Int
+type amount struct {
+	dest **big.Int
+}
+
+var _ sql.Scanner = amount{}
+
+func scanAmount(dest **big.Int) amount {
+	return amount{dest: dest}
+}
+
+func (a amount) Scan(src interface{}) error {
+	var raw string
+	switch value := src.(type) {
+	case nil:
+		*a.dest = nil
+		return nil
+	case int64:
+		*a.dest = big.NewInt(value)
+		return nil
+	case []byte:
+		raw = string(value)
+	case string:
+		raw = value
+	default:
+		return errors.Errorf("unsupported amount type: %T", src)
+	}
+
+	parsed, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return errors.Errorf("failed to parse amount: %s", raw)
+	}
+	*a.dest = parsed
+	return nil
+}
+
+// decimal scans numeric columns with fractions directly into big.Rat
+type decimal struct {
+	dest **big.Rat
+}
+
+var _ sql.Scanner = decimal{}
+
+func scanDecimal(dest **big.Rat) decimal {
+	return decimal{dest: dest}
+}
+
+func (d decimal) Scan(src interface{}) error {
+	var raw string
+	switch value := src.(type) {
+	case nil:
+		*d.dest = nil
+		return nil
+	case int64:
+		*d.dest = new(big.Rat).SetInt64(value)
+		return nil
+	case []byte:
+		raw = string(value)
+	case string:
+		raw = value
+	default:
+		return errors.Errorf("unsupported decimal type: %T", src)
+	}
+
+	parsed, ok := new(big.Rat).SetString(raw)
+	if !ok {
+		return errors.Errorf("failed to parse decimal: %s", raw)
+	}
+	*d.dest = parsed
+	return nil
+}
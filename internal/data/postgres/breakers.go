@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/pkg/errors"
+)
+
+// Breakers interface, which defines the functions to store states of circuit breakers
+type Breakers interface {
+	// Get returns the state of the breaker, the breaker is not tripped if its state was never stored
+	Get(ctx context.Context, name string) (data.CircuitBreaker, error)
+	// Trip trips the breaker keeping the reason of the first trip, returns false if it is already tripped
+	Trip(ctx context.Context, name, reason string) (bool, error)
+	// Reset closes the tripped breaker on behalf of the operator, returns false if it is not tripped
+	Reset(ctx context.Context, name, operator string) (bool, error)
+}
+
+type breakers struct {
+	db queryer
+}
+
+const (
+	breakersTable = "circuit_breakers"
+)
+
+func NewBreakers(cfg config.Config) Breakers {
+	return newBreakers(cfg.DB())
+}
+
+func newBreakers(db queryer) Breakers {
+	return &breakers{
+		db: db,
+	}
+}
+
+func (bs *breakers) Get(ctx context.Context, name string) (data.CircuitBreaker, error) {
+	breaker := data.CircuitBreaker{Name: name}
+	err := sq.Select("tripped_at", "reason", "reset_at", "reset_by").
+		From(breakersTable).
+		Where(sq.Eq{"name": name}).
+		RunWith(bs.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryRowContext(ctx).
+		Scan(&breaker.TrippedAt, &breaker.Reason, &breaker.ResetAt, &breaker.ResetBy)
+	if err == sql.ErrNoRows {
+		return breaker, nil
+	}
+	if err != nil {
+		return breaker, errors.Wrap(err, "failed to get circuit breaker")
+	}
+	return breaker, nil
+}
+
+func (bs *breakers) Trip(ctx context.Context, name, reason string) (bool, error) {
+	result, err := sq.Insert(breakersTable).
+		Columns("name", "tripped_at", "reason").
+		Values(name, sq.Expr("now()"), reason).
+		Suffix("ON CONFLICT (name) DO UPDATE SET tripped_at = excluded.tripped_at, reason = excluded.reason " +
+			"WHERE " + breakersTable + ".tripped_at IS NULL").
+		RunWith(bs.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to trip circuit breaker")
+	}
+	return affected(result)
+}
+
+func (bs *breakers) Reset(ctx context.Context, name, operator string) (bool, error) {
+	result, err := sq.Update(breakersTable).
+		Set("tripped_at", nil).
+		Set("reset_at", sq.Expr("now()")).
+		Set("reset_by", operator).
+		Where(sq.Eq{"name": name}).
+		Where(sq.NotEq{"tripped_at": nil}).
+		RunWith(bs.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to reset circuit breaker")
+	}
+	return affected(result)
+}
+
+func affected(result sql.Result) (bool, error) {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get number of affected rows")
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"io"
+	"sync"
+	"testing"
+)
+
+// recordingDriver records executed statements and returns empty results
+type recordingDriver struct {
+	mu      sync.Mutex
+	queries []recordedQuery
+}
+
+type recordedQuery struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+func (d *recordingDriver) record(query string, args []driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, recordedQuery{query: query, args: args})
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{driver: c.driver, query: query}, nil
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *recordingConn) Commit() error {
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	return nil
+}
+
+type recordingStmt struct {
+	driver *recordingDriver
+	query  string
+}
+
+func (s *recordingStmt) Close() error {
+	return nil
+}
+
+func (s *recordingStmt) NumInput() int {
+	return -1
+}
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.record(s.query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.record(s.query, args)
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string {
+	return nil
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+func (emptyRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+var (
+	recorder         = &recordingDriver{}
+	registerRecorder sync.Once
+)
+
+func openRecorder(t *testing.T) *sql.DB {
+	registerRecorder.Do(func() {
+		sql.Register("recorder", recorder)
+	})
+
+	db, err := sql.Open("recorder", "")
+	if err != nil {
+		t.Fatalf("failed to open recorder: %s", err)
+	}
+	return db
+}
+
+func TestRepositories_ConcurrentUse(t *testing.T) {
+	db := openRecorder(t)
+	defer db.Close()
+
+	users := newUsers(db)
+	transfers := newTransfers(db)
+	ctx := context.Background()
+
+	const workers = 50
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			address := fmt.Sprintf("0x%040d", i)
+
+			if _, err := users.GetUser(ctx, address, "odin"); err != nil {
+				t.Errorf("failed to get user: %s", err)
+			}
+			if _, err := users.GetUserById(ctx, int64(i)); err != nil {
+				t.Errorf("failed to get user by id: %s", err)
+			}
+			if _, err := transfers.FilterByStatus(data.StatusNotSent).FilterByUserAddress(address).Select(ctx); err != nil {
+				t.Errorf("failed to select transfers: %s", err)
+			}
+			if _, err := transfers.SelectStatus(ctx, data.StatusFailed); err != nil {
+				t.Errorf("failed to select transfers by status: %s", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if len(recorder.queries) != workers*4 {
+		t.Fatalf("expected %d queries, got %d", workers*4, len(recorder.queries))
+	}
+
+	// filters of different calls must not pile up on the shared repositories
+	expectedArgs := map[int]bool{1: true, 2: true}
+	for _, query := range recorder.queries {
+		if !expectedArgs[len(query.args)] {
+			t.Errorf("query has leaked filters: %s %v", query.query, query.args)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// IdempotencyKeys interface, which defines the functions to store responses of idempotent requests.
+// The key is changed only by the request holding the reservation, others get ErrReservationLost.
+type IdempotencyKeys interface {
+	// Reserve inserts the key without response, returns false if the key is already stored and not expired
+	Reserve(ctx context.Context, key data.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, key string) (*data.IdempotencyKey, error)
+	// Commit marks the unexpired reservation committed and keeps it until expiresAt, it is called
+	// in the transaction of the request changes, so the key is never released once they are committed
+	Commit(ctx context.Context, reservation data.IdempotencyKey, expiresAt time.Time) error
+	// Complete stores the response of the reservation and keeps it until expiresAt
+	Complete(ctx context.Context, reservation data.IdempotencyKey, statusCode int, response []byte, expiresAt time.Time) error
+	// Release deletes the reservation, which is neither committed nor completed
+	Release(ctx context.Context, reservation data.IdempotencyKey) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+var ErrReservationLost = errors.New("idempotency key is not reserved by the request anymore")
+
+type idempotencyKeys struct {
+	db queryer
+}
+
+const (
+	idempotencyKeysTable = "idempotency_keys"
+)
+
+func NewIdempotencyKeys(cfg config.Config) IdempotencyKeys {
+	return newIdempotencyKeys(cfg.DB())
+}
+
+func newIdempotencyKeys(db queryer) IdempotencyKeys {
+	return &idempotencyKeys{
+		db: db,
+	}
+}
+
+func (k *idempotencyKeys) Reserve(ctx context.Context, key data.IdempotencyKey) (bool, error) {
+	_, err := sq.Delete(idempotencyKeysTable).
+		Where(sq.Eq{"key": key.Key}).
+		Where("expires_at <= now()").
+		RunWith(k.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to delete expired idempotency key")
+	}
+
+	result, err := sq.Insert(idempotencyKeysTable).
+		SetMap(map[string]interface{}{
+			"key":          key.Key,
+			"request_hash": key.RequestHash,
+			"reservation":  key.Reservation,
+			"expires_at":   key.ExpiresAt,
+		}).
+		Suffix("ON CONFLICT (key) DO NOTHING").
+		RunWith(k.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to insert idempotency key")
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get number of inserted idempotency keys")
+	}
+	return inserted == 1, nil
+}
+
+func (k *idempotencyKeys) Get(ctx context.Context, key string) (*data.IdempotencyKey, error) {
+	result := data.IdempotencyKey{}
+	err := sq.Select("key", "request_hash", "status_code", "response", "committed_at", "created_at", "expires_at").
+		From(idempotencyKeysTable).
+		Where(sq.Eq{"key": key}).
+		RunWith(k.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryRowContext(ctx).
+		Scan(
+			&result.Key,
+			&result.RequestHash,
+			&result.StatusCode,
+			&result.Response,
+			&result.CommittedAt,
+			&result.CreatedAt,
+			&result.ExpiresAt,
+		)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query idempotency key")
+	}
+	return &result, nil
+}
+
+// reserved is the condition of the key held by the request, which response is not stored yet
+func reserved(reservation data.IdempotencyKey) sq.Eq {
+	return sq.Eq{
+		"key":          reservation.Key,
+		"request_hash": reservation.RequestHash,
+		"reservation":  reservation.Reservation,
+		"status_code":  nil,
+	}
+}
+
+// changed returns ErrReservationLost if the statement changed nothing
+func changed(result sql.Result, err error, action string) error {
+	if err != nil {
+		return errors.Wrapf(err, "failed to %s idempotency key", action)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get number of idempotency keys to %s", action)
+	}
+	if affected == 0 {
+		return errors.Wrapf(ErrReservationLost, "failed to %s idempotency key", action)
+	}
+	return nil
+}
+
+func (k *idempotencyKeys) Commit(ctx context.Context, reservation data.IdempotencyKey, expiresAt time.Time) error {
+	result, err := sq.Update(idempotencyKeysTable).
+		Set("committed_at", sq.Expr("now()")).
+		Set("expires_at", expiresAt).
+		Where(reserved(reservation)).
+		Where("expires_at > now()").
+		RunWith(k.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	return changed(result, err, "commit")
+}
+
+func (k *idempotencyKeys) Complete(
+	ctx context.Context,
+	reservation data.IdempotencyKey,
+	statusCode int,
+	response []byte,
+	expiresAt time.Time,
+) error {
+	result, err := sq.Update(idempotencyKeysTable).
+		Set("status_code", statusCode).
+		Set("response", response).
+		Set("expires_at", expiresAt).
+		Where(reserved(reservation)).
+		RunWith(k.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	return changed(result, err, "complete")
+}
+
+func (k *idempotencyKeys) Release(ctx context.Context, reservation data.IdempotencyKey) error {
+	result, err := sq.Delete(idempotencyKeysTable).
+		Where(reserved(reservation)).
+		Where(sq.Eq{"committed_at": nil}).
+		RunWith(k.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	return changed(result, err, "release")
+}
+
+func (k *idempotencyKeys) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := sq.Delete(idempotencyKeysTable).
+		Where("expires_at <= now()").
+		RunWith(k.db).
+		PlaceholderFormat(sq.Dollar).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to delete expired idempotency keys")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get number of deleted idempotency keys")
+	}
+	return deleted, nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/pkg/errors"
+)
+
+// Ledger interface, which defines the functions to append and project the ledger
+type Ledger interface {
+	CreateJournal(ctx context.Context, journal data.LedgerJournal) (int64, error)
+	SelectEntries(ctx context.Context, account data.Account, userID int64) ([]data.LedgerEntry, error)
+	RebuildBalances(ctx context.Context) (int64, error)
+	// SelectBalances sums the entries of the account by denoms
+	SelectBalances(ctx context.Context, account data.Account) ([]data.AccountBalance, error)
+}
+
+type ledger struct {
+	db queryer
+}
+
+const (
+	ledgerJournalsTable = "ledger_journals"
+	ledgerEntriesTable  = "ledger_entries"
+)
+
+var ledgerEntriesColumns = []string{
+	"id",
+	"journal_id",
+	"account",
+	"user_id",
+	"denom",
+	"debit",
+	"credit",
+}
+
+func NewLedger(cfg config.Config) Ledger {
+	return newLedger(cfg.DB())
+}
+
+func newLedger(db queryer) Ledger {
+	return &ledger{
+		db: db,
+	}
+}
+
+// CreateJournal inserts the journal with all its entries, should be called within transaction
+func (l *ledger) CreateJournal(ctx context.Context, journal data.LedgerJournal) (int64, error) {
+	if !journal.Balanced() {
+		return 0, errors.Errorf("journal %s is not balanced", journal.Kind)
+	}
+
+	var id int64
+	err := sq.Insert(ledgerJournalsTable).
+		SetMap(journal.ToMap()).
+		Suffix("RETURNING id").
+		RunWith(l.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryRowContext(ctx).
+		Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to insert ledger journal")
+	}
+
+	for _, entry := range journal.Entries {
+		entry.JournalID = id
+		_, err := sq.Insert(ledgerEntriesTable).
+			SetMap(entry.ToMap()).
+			RunWith(l.db).
+			PlaceholderFormat(sq.Dollar).
+			ExecContext(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to insert ledger entry")
+		}
+	}
+
+	return id, nil
+}
+
+// SelectEntries returns the history of the account ordered by insertion
+func (l *ledger) SelectEntries(ctx context.Context, account data.Account, userID int64) ([]data.LedgerEntry, error) {
+	rows, err := sq.Select(ledgerEntriesColumns...).
+		From(ledgerEntriesTable).
+		Where(sq.Eq{"account": account, "user_id": userID}).
+		OrderBy("id").
+		RunWith(l.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query ledger entries")
+	}
+	defer rows.Close()
+
+	result := make([]data.LedgerEntry, 0)
+	for rows.Next() {
+		entry := data.LedgerEntry{}
+		err = rows.Scan(
+			&entry.ID,
+			&entry.JournalID,
+			&entry.Account,
+			&entry.UserID,
+			&entry.Denom,
+			scanAmount(&entry.Debit),
+			scanAmount(&entry.Credit),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan ledger entry")
+		}
+		result = append(result, entry)
+	}
+
+	return result, errors.Wrap(rows.Err(), "failed to iterate ledger entries")
+}
+
+// RebuildBalances recalculates cached users balances from the ledger, returns the number of changed users
+func (l *ledger) RebuildBalances(ctx context.Context) (int64, error) {
+	result, err := l.db.ExecContext(ctx, `
+		update users u
+		set amount = b.amount
+		from (
+			select u.id, coalesce(sum(e.credit - e.debit), 0) as amount
+			from users u
+			left join ledger_entries e on e.account = $1 and e.user_id = u.id
+			group by u.id
+		) b
+		where u.id = b.id and u.amount is distinct from b.amount`,
+		data.AccountUser,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to rebuild balances")
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get number of rebuilt balances")
+	}
+	return changed, nil
+}
+
+func (l *ledger) SelectBalances(ctx context.Context, account data.Account) ([]data.AccountBalance, error) {
+	rows, err := sq.Select("denom", "sum(credit - debit)").
+		From(ledgerEntriesTable).
+		Where(sq.Eq{"account": account}).
+		GroupBy("denom").
+		OrderBy("denom").
+		RunWith(l.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query account balances")
+	}
+	defer rows.Close()
+
+	result := make([]data.AccountBalance, 0)
+	for rows.Next() {
+		balance := data.AccountBalance{Account: account}
+		if err := rows.Scan(&balance.Denom, scanAmount(&balance.Amount)); err != nil {
+			return nil, errors.Wrap(err, "failed to scan account balance")
+		}
+		result = append(result, balance)
+	}
+
+	return result, errors.Wrap(rows.Err(), "failed to iterate account balances")
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/pkg/errors"
+)
+
+// Reviews interface, which defines the functions to record decisions on held transfers
+type Reviews interface {
+	CreateReview(context.Context, data.TransferReview) (int64, error)
+	SelectReviews(ctx context.Context, transferID int64) ([]data.TransferReview, error)
+}
+
+type reviews struct {
+	db queryer
+}
+
+const (
+	reviewsTable = "transfer_reviews"
+)
+
+func NewReviews(cfg config.Config) Reviews {
+	return newReviews(cfg.DB())
+}
+
+func newReviews(db queryer) Reviews {
+	return &reviews{
+		db: db,
+	}
+}
+
+func (rs *reviews) CreateReview(ctx context.Context, review data.TransferReview) (int64, error) {
+	var id int64
+	err := sq.Insert(reviewsTable).
+		SetMap(review.ToMap()).
+		Suffix("RETURNING id").
+		RunWith(rs.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryRowContext(ctx).
+		Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to insert transfer review")
+	}
+	return id, nil
+}
+
+func (rs *reviews) SelectReviews(ctx context.Context, transferID int64) ([]data.TransferReview, error) {
+	rows, err := sq.Select("id", "transfer_id", "decision", "operator", "reason", "created_at").
+		From(reviewsTable).
+		Where(sq.Eq{"transfer_id": transferID}).
+		OrderBy("id").
+		RunWith(rs.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query transfer reviews")
+	}
+	defer rows.Close()
+
+	result := make([]data.TransferReview, 0)
+	for rows.Next() {
+		review := data.TransferReview{}
+		err = rows.Scan(
+			&review.ID,
+			&review.TransferID,
+			&review.Decision,
+			&review.Operator,
+			&review.Reason,
+			&review.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan transfer review")
+		}
+		result = append(result, review)
+	}
+
+	return result, errors.Wrap(rows.Err(), "failed to iterate transfer reviews")
+}
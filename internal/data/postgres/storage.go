@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// queryer is implemented by both sql.DB and sql.Tx, so repositories can run inside a transaction
+type queryer interface {
+	sq.StdSqlCtx
+}
+
+// Storage provides repositories, which share the same connection or transaction
+type Storage interface {
+	Users() Users
+	Transfers() Transfers
+	Ledger() Ledger
+	IdempotencyKeys() IdempotencyKeys
+	Reviews() Reviews
+	Breakers() Breakers
+	// Transaction runs fn in a transaction, which is rolled back if fn returns an error or panics
+	Transaction(ctx context.Context, fn func(Storage) error) error
+	// Lock takes exclusive locks on the keys until the end of the transaction, it is allowed in transaction only
+	Lock(ctx context.Context, keys ...string) error
+}
+
+var ErrNotInTransaction = errors.New("storage is not in transaction")
+
+type storage struct {
+	db *sql.DB
+	q  queryer
+}
+
+func NewStorage(cfg config.Config) Storage {
+	return &storage{
+		db: cfg.DB(),
+		q:  cfg.DB(),
+	}
+}
+
+func (s *storage) Users() Users {
+	return newUsers(s.q)
+}
+
+func (s *storage) Transfers() Transfers {
+	return newTransfers(s.q)
+}
+
+func (s *storage) Ledger() Ledger {
+	return newLedger(s.q)
+}
+
+func (s *storage) IdempotencyKeys() IdempotencyKeys {
+	return newIdempotencyKeys(s.q)
+}
+
+func (s *storage) Reviews() Reviews {
+	return newReviews(s.q)
+}
+
+func (s *storage) Breakers() Breakers {
+	return newBreakers(s.q)
+}
+
+func (s *storage) Lock(ctx context.Context, keys ...string) error {
+	if s.db != nil {
+		return ErrNotInTransaction
+	}
+
+	// keys are locked in the same order by all transactions, so they do not deadlock
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		if _, err := s.q.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+			return errors.Wrapf(err, "failed to lock %s", key)
+		}
+	}
+	return nil
+}
+
+func (s *storage) Transaction(ctx context.Context, fn func(Storage) error) (err error) {
+	// already in transaction
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			_ = tx.Rollback()
+			panic(rvr)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(&storage{q: tx}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	return nil
+}
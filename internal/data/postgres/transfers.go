@@ -1,86 +1,195 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/bsc-bridge-svc/internal/config"
 	"github.com/bsc-bridge-svc/internal/data"
 	"github.com/pkg/errors"
+	"strings"
+	"time"
 )
 
 type Transfers interface {
 	New() Transfers
-	SelectStatus(data.Status) ([]data.Transfer, error)
-	Select() ([]data.Transfer, error)
-	CreateTransfer(data.Transfer) error
-	UpdateTransfer(data.Transfer) error
+	FilterByStatus(...data.Status) Transfers
+	FilterByDenom(...string) Transfers
+	FilterByAddress(string) Transfers
+	FilterByUserAddress(string) Transfers
+	FilterByCreatedAt(from, to *time.Time) Transfers
+	Page(data.PageParams) Transfers
+	SelectStatus(context.Context, data.Status) ([]data.Transfer, error)
+	Select(context.Context) ([]data.Transfer, error)
+	Get(ctx context.Context, id int64) (*data.Transfer, error)
+	CreateTransfer(context.Context, data.Transfer) (int64, error)
+	// UpdateTransfer updates details of the transfer, the status is changed only by Transit. The processing transfer
+	// is updated only by the owner of the unexpired lease, returns ErrStatusConflict otherwise
+	UpdateTransfer(context.Context, data.Transfer) error
+	// Transit moves the transfer from its current status to another one and records the event,
+	// returns ErrStatusConflict if the status was changed concurrently
+	Transit(ctx context.Context, transfer data.Transfer, to data.Status, reason string) error
+	SelectEvents(ctx context.Context, transferID int64) ([]data.TransferEvent, error)
+	// Claim moves up to limit not sent transfers, which are due to be attempted, to processing and leases them to the owner,
+	// transfers locked by other instances are skipped
+	Claim(ctx context.Context, owner string, lease time.Duration, limit uint64) ([]data.Transfer, error)
+	// RecoverExpired returns processing transfers with expired lease back to not sent,
+	// the ones which transaction may be broadcast become unknown
+	RecoverExpired(ctx context.Context) (int64, error)
+	// SelectOutflow sums amounts of transfers sent since the time and the ones being sent, grouped by denoms
+	SelectOutflow(ctx context.Context, since time.Time) ([]data.TransferOutflow, error)
 }
 
+var ErrStatusConflict = errors.New("transfer status was changed concurrently")
+
+// transfers is immutable, filters return a copy of the repository,
+// so the same instance is safe for concurrent use
 type transfers struct {
-	db  *sql.DB
+	db  queryer
 	sql sq.SelectBuilder
 }
 
 const (
-	transfersTable = "transfers"
+	transfersTable      = "transfers"
+	transferEventsTable = "transfer_events"
 )
 
-var transfersSelect = sq.Select(all).From(transfersTable).PlaceholderFormat(sq.Dollar)
+var transfersColumns = []string{
+	"id",
+	"address",
+	"amount",
+	"denom",
+	"status",
+	"user_id",
+	"created_at",
+	"updated_at",
+	"sent_at",
+	"odin_tx_hash",
+	"odin_height",
+	"odin_timeout_height",
+	"odin_amount",
+	"odin_denom",
+	"odin_dust",
+	"fee",
+	"last_error",
+	"attempts",
+	"next_attempt_at",
+	"lease_owner",
+	"lease_expires_at",
+}
+
+var transfersSelect = sq.Select(transfersColumns...).From(transfersTable).PlaceholderFormat(sq.Dollar)
 
 func NewTransfers(cfg config.Config) Transfers {
+	return newTransfers(cfg.DB())
+}
+
+func newTransfers(db queryer) Transfers {
 	return &transfers{
-		db:  cfg.DB(),
-		sql: transfersSelect.RunWith(cfg.DB()),
+		db:  db,
+		sql: transfersSelect.RunWith(db),
 	}
 }
 
 func (t *transfers) New() Transfers {
-	return &transfers{
-		db:  t.db,
-		sql: transfersSelect.RunWith(t.db),
-	}
+	return newTransfers(t.db)
 }
 
 func (t *transfers) newInsert() sq.InsertBuilder {
-	return sq.Insert(transfersTable).RunWith(t.db).PlaceholderFormat(sq.Dollar)
+	return sq.Insert(transfersTable).RunWith(t.db).PlaceholderFormat(sq.Dollar).Suffix("RETURNING id")
 }
 
-func (t *transfers) CreateTransfer(transfer data.Transfer) error {
-	_, err := t.newInsert().SetMap(transfer.ToMap()).Exec()
+func (t *transfers) CreateTransfer(ctx context.Context, transfer data.Transfer) (int64, error) {
+	var id int64
+	err := t.newInsert().SetMap(transfer.ToMap()).QueryRowContext(ctx).Scan(&id)
 	if err != nil {
-		return errors.Wrap(err, "failed to insert transfer")
+		return 0, errors.Wrap(err, "failed to insert transfer")
 	}
-	return nil
+	return id, nil
 }
 
 func (t *transfers) newUpdate() sq.UpdateBuilder {
 	return sq.Update(transfersTable).RunWith(t.db).PlaceholderFormat(sq.Dollar)
 }
 
-func (t *transfers) UpdateTransfer(transfer data.Transfer) error {
-	_, err := t.newUpdate().SetMap(transfer.ToMap()).Where(sq.Eq{"id": transfer.ID}).Exec()
+func (t *transfers) UpdateTransfer(ctx context.Context, transfer data.Transfer) error {
+	values := transfer.ToMap()
+	delete(values, "status")
+	delete(values, "lease_owner")
+	delete(values, "lease_expires_at")
+
+	condition := sq.And{sq.Eq{"id": transfer.ID, "status": transfer.Status}}
+	// the transfer may be already recovered and claimed by another instance once the lease is expired
+	if transfer.Status == data.StatusProcessing && transfer.LeaseOwner != nil {
+		condition = append(condition, sq.Eq{"lease_owner": *transfer.LeaseOwner}, sq.Expr("lease_expires_at > now()"))
+	}
+
+	result, err := t.newUpdate().
+		SetMap(values).
+		Set("updated_at", sq.Expr("now()")).
+		Where(condition).
+		ExecContext(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to update transfer data")
 	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to get number of updated transfers")
+	}
+	if updated == 0 {
+		return errors.Wrapf(ErrStatusConflict, "transfer %d is not %s anymore or its lease is expired", transfer.ID, transfer.Status)
+	}
 	return nil
 }
 
-func (t *transfers) Select() ([]data.Transfer, error) {
-	rows, err := t.sql.Query()
+func (t *transfers) Select(ctx context.Context) ([]data.Transfer, error) {
+	rows, err := t.sql.QueryContext(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query rows")
 	}
+	defer rows.Close()
+
+	result, err := scanTransfers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func scanTransfers(rows *sql.Rows) ([]data.Transfer, error) {
 	result := make([]data.Transfer, 0)
 
 	for rows.Next() {
 		transfer := data.Transfer{}
-		err = rows.Scan(
+		err := rows.Scan(
 			&transfer.ID,
 			&transfer.Address,
-			&transfer.Amount,
+			scanAmount(&transfer.Amount),
 			&transfer.Denom,
 			&transfer.Status,
 			&transfer.UserID,
+			&transfer.CreatedAt,
+			&transfer.UpdatedAt,
+			&transfer.SentAt,
+			&transfer.OdinTxHash,
+			&transfer.OdinHeight,
+			&transfer.OdinTimeoutHeight,
+			scanAmount(&transfer.OdinAmount),
+			&transfer.OdinDenom,
+			scanDecimal(&transfer.OdinDust),
+			scanAmount(&transfer.Fee),
+			&transfer.LastError,
+			&transfer.Attempts,
+			&transfer.NextAttemptAt,
+			&transfer.LeaseOwner,
+			&transfer.LeaseExpiresAt,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan rows")
@@ -88,14 +197,270 @@ func (t *transfers) Select() ([]data.Transfer, error) {
 		result = append(result, transfer)
 	}
 
+	return result, errors.Wrap(rows.Err(), "failed to iterate rows")
+}
+
+func (t *transfers) SelectStatus(ctx context.Context, status data.Status) ([]data.Transfer, error) {
+	return t.FilterByStatus(status).Select(ctx)
+}
+
+func (t *transfers) Get(ctx context.Context, id int64) (*data.Transfer, error) {
+	result, err := t.where(sq.Eq{"id": id}).Select(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if len(result) == 0 {
 		return nil, nil
 	}
+	return &result[0], nil
+}
 
-	return result, nil
+// where returns the copy of repository with condition added, so filters do not affect the origin
+func (t *transfers) where(pred interface{}, args ...interface{}) *transfers {
+	return &transfers{
+		db:  t.db,
+		sql: t.sql.Where(pred, args...),
+	}
+}
+
+func (t *transfers) FilterByStatus(statuses ...data.Status) Transfers {
+	return t.where(sq.Eq{"status": statuses})
+}
+
+func (t *transfers) FilterByDenom(denoms ...string) Transfers {
+	return t.where(sq.Eq{"denom": denoms})
+}
+
+// FilterByAddress filters transfers by odin address of the receiver
+func (t *transfers) FilterByAddress(address string) Transfers {
+	return t.where(sq.Eq{"address": address})
+}
+
+// FilterByUserAddress filters transfers by binance address of the user
+func (t *transfers) FilterByUserAddress(address string) Transfers {
+	return t.where(fmt.Sprintf("user_id in (select id from %s where address = ?)", usersTable), address)
 }
 
-func (t *transfers) SelectStatus(status data.Status) ([]data.Transfer, error) {
-	t.sql = t.sql.Where(sq.Eq{"status": status})
-	return t.Select()
+func (t *transfers) FilterByCreatedAt(from, to *time.Time) Transfers {
+	result := t
+	if from != nil {
+		result = result.where(sq.GtOrEq{"created_at": *from})
+	}
+	if to != nil {
+		result = result.where(sq.Lt{"created_at": *to})
+	}
+	return result
+}
+
+// Page applies keyset pagination by id, the cursor is the id of the last transfer on previous page
+func (t *transfers) Page(params data.PageParams) Transfers {
+	result, order := t, "id desc"
+	if params.Order == data.OrderAsc {
+		order = "id asc"
+	}
+
+	if params.Cursor != nil {
+		if params.Order == data.OrderAsc {
+			result = result.where(sq.Gt{"id": *params.Cursor})
+		} else {
+			result = result.where(sq.Lt{"id": *params.Cursor})
+		}
+	}
+
+	return &transfers{
+		db:  t.db,
+		sql: result.sql.OrderBy(order).Limit(params.Limit),
+	}
+}
+
+func (t *transfers) Transit(ctx context.Context, transfer data.Transfer, to data.Status, reason string) error {
+	if !data.CanTransit(transfer.Status, to) {
+		return errors.Wrapf(data.ErrTransitionNotAllowed, "from %s to %s", transfer.Status, to)
+	}
+
+	condition := sq.Eq{"id": transfer.ID, "status": transfer.Status}
+	// only the owner of the lease may move the transfer out of processing
+	if transfer.Status == data.StatusProcessing && transfer.LeaseOwner != nil {
+		condition["lease_owner"] = *transfer.LeaseOwner
+	}
+
+	values := transfer.ToMap()
+	values["status"] = to
+	if to != data.StatusProcessing {
+		values["lease_owner"] = nil
+		values["lease_expires_at"] = nil
+	}
+
+	// the event is inserted only if the conditional update succeeded
+	update, args, err := sq.Update(transfersTable).
+		SetMap(values).
+		Set("updated_at", sq.Expr("now()")).
+		Where(condition).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "failed to build transfer update")
+	}
+
+	query, err := sq.Dollar.ReplacePlaceholders(fmt.Sprintf(
+		"WITH updated AS (%s) INSERT INTO %s (transfer_id, from_status, to_status, reason) SELECT id, ?, ?, ? FROM updated",
+		update, transferEventsTable,
+	))
+	if err != nil {
+		return errors.Wrap(err, "failed to build transfer transition")
+	}
+
+	result, err := t.db.ExecContext(ctx, query, append(args, transfer.Status, to, reason)...)
+	if err != nil {
+		return errors.Wrap(err, "failed to transit transfer")
+	}
+
+	transited, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to get number of transited transfers")
+	}
+	if transited == 0 {
+		return errors.Wrapf(ErrStatusConflict, "transfer %d is not %s anymore", transfer.ID, transfer.Status)
+	}
+	return nil
+}
+
+func (t *transfers) SelectEvents(ctx context.Context, transferID int64) ([]data.TransferEvent, error) {
+	rows, err := sq.Select("id", "transfer_id", "from_status", "to_status", "reason", "created_at").
+		From(transferEventsTable).
+		Where(sq.Eq{"transfer_id": transferID}).
+		OrderBy("id").
+		RunWith(t.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query transfer events")
+	}
+	defer rows.Close()
+
+	result := make([]data.TransferEvent, 0)
+	for rows.Next() {
+		event := data.TransferEvent{}
+		err = rows.Scan(
+			&event.ID,
+			&event.TransferID,
+			&event.FromStatus,
+			&event.ToStatus,
+			&event.Reason,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan transfer event")
+		}
+		result = append(result, event)
+	}
+
+	return result, errors.Wrap(rows.Err(), "failed to iterate transfer events")
+}
+
+// qualifiedTransfersColumns prefixes columns with the table name, so they are not ambiguous in joins
+func qualifiedTransfersColumns() string {
+	columns := make([]string, 0, len(transfersColumns))
+	for _, column := range transfersColumns {
+		columns = append(columns, transfersTable+"."+column)
+	}
+	return strings.Join(columns, ", ")
+}
+
+func (t *transfers) Claim(ctx context.Context, owner string, lease time.Duration, limit uint64) ([]data.Transfer, error) {
+	// SKIP LOCKED lets several instances claim different transfers concurrently,
+	// the events are inserted for the claimed transfers only
+	query, err := sq.Dollar.ReplacePlaceholders(fmt.Sprintf(`WITH claimed AS (
+		SELECT id FROM %[1]s WHERE status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+		ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED
+	), updated AS (
+		UPDATE %[1]s SET status = ?, lease_owner = ?, lease_expires_at = now() + make_interval(secs => ?), updated_at = now()
+		FROM claimed WHERE %[1]s.id = claimed.id
+		RETURNING %[2]s
+	), events AS (
+		INSERT INTO %[3]s (transfer_id, from_status, to_status, reason) SELECT id, ?, ?, ? FROM updated
+	)
+	SELECT * FROM updated ORDER BY id`, transfersTable, qualifiedTransfersColumns(), transferEventsTable))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build transfers claim")
+	}
+
+	rows, err := t.db.QueryContext(ctx, query,
+		data.StatusNotSent, limit,
+		data.StatusProcessing, owner, lease.Seconds(),
+		data.StatusNotSent, data.StatusProcessing, "claimed by "+owner,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to claim transfers")
+	}
+	defer rows.Close()
+
+	return scanTransfers(rows)
+}
+
+func (t *transfers) RecoverExpired(ctx context.Context) (int64, error) {
+	query, err := sq.Dollar.ReplacePlaceholders(fmt.Sprintf(`WITH expired AS (
+		SELECT id FROM %[1]s WHERE status = ? AND lease_expires_at < now() FOR UPDATE SKIP LOCKED
+	), updated AS (
+		UPDATE %[1]s SET status = CASE WHEN odin_tx_hash IS NULL THEN ? ELSE ? END,
+			lease_owner = NULL, lease_expires_at = NULL, updated_at = now()
+		FROM expired WHERE %[1]s.id = expired.id
+		RETURNING %[1]s.id, %[1]s.status
+	)
+	INSERT INTO %[2]s (transfer_id, from_status, to_status, reason) SELECT id, ?, status, ? FROM updated`,
+		transfersTable, transferEventsTable))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build expired leases recovery")
+	}
+
+	result, err := t.db.ExecContext(ctx, query,
+		data.StatusProcessing, data.StatusNotSent, data.StatusUnknown,
+		data.StatusProcessing, "lease expired",
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to recover expired leases")
+	}
+
+	recovered, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get number of recovered transfers")
+	}
+	return recovered, nil
+}
+
+func (t *transfers) SelectOutflow(ctx context.Context, since time.Time) ([]data.TransferOutflow, error) {
+	// transactions of processing and unknown transfers may be already included
+	rows, err := sq.Select("denom", "odin_denom", "sum(amount)", "sum(odin_amount)").
+		From(transfersTable).
+		Where(sq.NotEq{"odin_amount": nil}).
+		Where(sq.Or{
+			sq.Eq{"status": []data.Status{data.StatusProcessing, data.StatusUnknown}},
+			sq.And{sq.Eq{"status": data.StatusSent}, sq.GtOrEq{"sent_at": since}},
+		}).
+		GroupBy("denom", "odin_denom").
+		OrderBy("denom", "odin_denom").
+		RunWith(t.db).
+		PlaceholderFormat(sq.Dollar).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query outflow")
+	}
+	defer rows.Close()
+
+	result := make([]data.TransferOutflow, 0)
+	for rows.Next() {
+		outflow := data.TransferOutflow{}
+		err = rows.Scan(
+			&outflow.Denom,
+			&outflow.OdinDenom,
+			scanAmount(&outflow.Amount),
+			scanAmount(&outflow.OdinAmount),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan outflow")
+		}
+		result = append(result, outflow)
+	}
+
+	return result, errors.Wrap(rows.Err(), "failed to iterate outflow")
 }
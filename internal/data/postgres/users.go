@@ -1,58 +1,84 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	sq "github.com/Masterminds/squirrel"
+	amounts "github.com/bsc-bridge-svc/internal/amount"
 	"github.com/bsc-bridge-svc/internal/config"
 	"github.com/bsc-bridge-svc/internal/data"
 	"github.com/pkg/errors"
+	"math/big"
 )
 
 // Users interface, which defines the main functions to query the underlying postgres database
 type Users interface {
 	New() Users
-	Get() (*data.User, error)
-	GetUser(address, denom string) (*data.User, error)
-	GetUserById(id int64) (*data.User, error)
-	CreateUser(user data.User) (int64, error)
-	UpdateUser(user data.User) error
-	DeleteUser(address string) error
+	Get(ctx context.Context) (*data.User, error)
+	GetUser(ctx context.Context, address, denom string) (*data.User, error)
+	GetUserById(ctx context.Context, id int64) (*data.User, error)
+	// GetUserForUpdate returns the user by id and locks it until the end of the transaction
+	GetUserForUpdate(ctx context.Context, id int64) (*data.User, error)
+	CreateUser(ctx context.Context, user data.User) (int64, error)
+	UpdateUser(ctx context.Context, user data.User) error
+	AddAmount(ctx context.Context, id int64, delta *big.Int) error
+	// AddOdinDust accumulates the dust dropped by rounding of the user payout
+	AddOdinDust(ctx context.Context, id int64, dust *big.Rat) error
+	DeleteUser(ctx context.Context, address string) error
 }
 
+// users is immutable, every query method builds its statement locally,
+// so the same instance is safe for concurrent use
 type users struct {
-	db  *sql.DB
+	db  queryer
 	sql sq.SelectBuilder
 }
 
 const (
-	all        = "*"
 	usersTable = "users"
 )
 
-var usersSelect = sq.Select(all).From(usersTable).PlaceholderFormat(sq.Dollar)
+var usersColumns = []string{
+	"id",
+	"address",
+	"amount",
+	"denom",
+	"odin_dust",
+	"merged_into",
+	"over_claimed",
+}
+
+var usersSelect = sq.Select(usersColumns...).From(usersTable).PlaceholderFormat(sq.Dollar)
 
 func NewUsers(cfg config.Config) Users {
+	return newUsers(cfg.DB())
+}
+
+func newUsers(db queryer) Users {
 	return &users{
-		db:  cfg.DB(),
-		sql: usersSelect.RunWith(cfg.DB()),
+		db:  db,
+		sql: usersSelect.RunWith(db),
 	}
 }
 
 func (us *users) New() Users {
-	return &users{
-		db:  us.db,
-		sql: usersSelect.RunWith(us.db),
-	}
+	return newUsers(us.db)
 }
 
-func (us *users) Get() (*data.User, error) {
-	rowScanner := us.sql.QueryRow()
+func (us *users) Get(ctx context.Context) (*data.User, error) {
+	return us.get(ctx, us.sql)
+}
+
+func (us *users) get(ctx context.Context, stmt sq.SelectBuilder) (*data.User, error) {
 	user := data.User{}
-	err := rowScanner.Scan(
+	err := stmt.QueryRowContext(ctx).Scan(
 		&user.ID,
 		&user.Address,
-		&user.Amount,
+		scanAmount(&user.Amount),
 		&user.Denom,
+		scanDecimal(&user.OdinDust),
+		&user.MergedInto,
+		scanAmount(&user.OverClaimed),
 	)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, errors.Wrap(err, "failed to query user")
@@ -62,28 +88,26 @@ func (us *users) Get() (*data.User, error) {
 	return &user, nil
 }
 
-func (us *users) GetUser(address, denom string) (*data.User, error) {
-	us.sql = us.sql.Where(sq.Eq{"address": address, "denom": denom})
-	return us.Get()
+// GetUser returns the user of the canonical address, duplicates merged into other users are skipped
+func (us *users) GetUser(ctx context.Context, address, denom string) (*data.User, error) {
+	return us.get(ctx, us.sql.Where(sq.Eq{"address": address, "denom": denom, "merged_into": nil}))
+}
+
+func (us *users) GetUserById(ctx context.Context, id int64) (*data.User, error) {
+	return us.get(ctx, us.sql.Where(sq.Eq{"id": id}))
 }
 
-func (us *users) GetUserById(id int64) (*data.User, error) {
-	us.sql = us.sql.Where(sq.Eq{"id": id})
-	return us.Get()
+func (us *users) GetUserForUpdate(ctx context.Context, id int64) (*data.User, error) {
+	return us.get(ctx, us.sql.Where(sq.Eq{"id": id}).Suffix("FOR UPDATE"))
 }
 
 func (us *users) newInsert() sq.InsertBuilder {
 	return sq.Insert(usersTable).RunWith(us.db).PlaceholderFormat(sq.Dollar).Suffix("RETURNING id")
 }
 
-func (us *users) CreateUser(user data.User) (int64, error) {
-	rawSql, args, err := us.newInsert().SetMap(user.ToMap()).ToSql()
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to build sql query")
-	}
-
+func (us *users) CreateUser(ctx context.Context, user data.User) (int64, error) {
 	var id int64
-	err = us.db.QueryRow(rawSql, args...).Scan(&id)
+	err := us.newInsert().SetMap(user.ToMap()).QueryRowContext(ctx).Scan(&id)
 	if err != nil {
 		return 0, errors.Wrap(err, "failed to insert user")
 	}
@@ -94,20 +118,40 @@ func (us *users) newUpdate() sq.UpdateBuilder {
 	return sq.Update(usersTable).RunWith(us.db).PlaceholderFormat(sq.Dollar)
 }
 
-func (us *users) UpdateUser(user data.User) error {
-	_, err := us.newUpdate().SetMap(user.ToMap()).Where(sq.Eq{"address": user.Address}).Exec()
+func (us *users) UpdateUser(ctx context.Context, user data.User) error {
+	_, err := us.newUpdate().SetMap(user.ToMap()).Where(sq.Eq{"address": user.Address}).ExecContext(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to update user data")
 	}
 	return nil
 }
 
+// AddAmount changes the cached balance of the user by delta
+func (us *users) AddAmount(ctx context.Context, id int64, delta *big.Int) error {
+	_, err := us.newUpdate().Set("amount", sq.Expr("amount + ?", delta.String())).Where(sq.Eq{"id": id}).ExecContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to update user amount")
+	}
+	return nil
+}
+
+func (us *users) AddOdinDust(ctx context.Context, id int64, dust *big.Rat) error {
+	_, err := us.newUpdate().
+		Set("odin_dust", sq.Expr("odin_dust + ?::numeric", amounts.FormatDecimal(dust))).
+		Where(sq.Eq{"id": id}).
+		ExecContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to update user dust")
+	}
+	return nil
+}
+
 func (us *users) newDelete() sq.DeleteBuilder {
 	return sq.Delete(usersTable).RunWith(us.db).PlaceholderFormat(sq.Dollar)
 }
 
-func (us *users) DeleteUser(address string) error {
-	_, err := us.newDelete().Where(sq.Eq{"address": address}).Exec()
+func (us *users) DeleteUser(ctx context.Context, address string) error {
+	_, err := us.newDelete().Where(sq.Eq{"address": address}).ExecContext(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to delete user")
 	}
@@ -1,10 +0,0 @@
-package postgres
-
-import (
-	"testing"
-)
-
-// todo
-func TestUsers_CreateUser(t *testing.T) {
-
-}
@@ -0,0 +1,46 @@
+package data
+
+import "time"
+
+type ReviewDecision string
+
+const (
+	ReviewHold    ReviewDecision = "hold"
+	ReviewApprove ReviewDecision = "approve"
+	ReviewReject  ReviewDecision = "reject"
+)
+
+// OperatorSystem is the operator of decisions made by the review policy
+const OperatorSystem = "system"
+
+// TransferReview records the decision on the held transfer
+type TransferReview struct {
+	ID         int64          `db:"id" json:"id"`
+	TransferID int64          `db:"transfer_id" json:"transfer_id"`
+	Decision   ReviewDecision `db:"decision" json:"decision"`
+	Operator   string         `db:"operator" json:"operator"`
+	Reason     string         `db:"reason" json:"reason"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+}
+
+func (r TransferReview) ToMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"transfer_id": r.TransferID,
+		"decision":    r.Decision,
+		"operator":    r.Operator,
+		"reason":      r.Reason,
+	}
+
+	return result
+}
+
+func (r TransferReview) ToReturn() map[string]interface{} {
+	result := map[string]interface{}{
+		"decision":   r.Decision,
+		"operator":   r.Operator,
+		"reason":     r.Reason,
+		"created_at": r.CreatedAt,
+	}
+
+	return result
+}
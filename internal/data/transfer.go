@@ -1,21 +1,61 @@
 package data
 
+import (
+	"github.com/bsc-bridge-svc/internal/amount"
+	"math/big"
+	"time"
+)
+
 type Transfer struct {
-	ID      int64  `db:"id" json:"id"`
-	Address string `db:"address" json:"address"`
-	Amount  string `db:"amount,omitempty" json:"amount,omitempty"`
-	Denom   string `db:"denom,omitempty" json:"denom"`
-	Status  Status `db:"status" json:"status"`
-	UserID  int64  `db:"user_id" json:"user_id"`
+	ID         int64      `db:"id" json:"id"`
+	Address    string     `db:"address" json:"address"`
+	Amount     *big.Int   `db:"amount,omitempty" json:"amount,omitempty"`
+	Denom      string     `db:"denom,omitempty" json:"denom"`
+	Status     Status     `db:"status" json:"status"`
+	UserID     int64      `db:"user_id" json:"user_id"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+	SentAt     *time.Time `db:"sent_at" json:"sent_at,omitempty"`
+	OdinTxHash *string    `db:"odin_tx_hash" json:"odin_tx_hash,omitempty"`
+	OdinHeight *int64     `db:"odin_height" json:"odin_height,omitempty"`
+	// OdinTimeoutHeight is the last height the transaction with OdinTxHash may be included at
+	OdinTimeoutHeight *int64 `db:"odin_timeout_height" json:"odin_timeout_height,omitempty"`
+	// OdinAmount is the amount of OdinDenom paid out by the transaction with OdinTxHash
+	OdinAmount *big.Int `db:"odin_amount" json:"odin_amount,omitempty"`
+	OdinDenom  *string  `db:"odin_denom" json:"odin_denom,omitempty"`
+	// OdinDust is the fraction of odin base unit dropped by rounding of OdinAmount
+	OdinDust *big.Rat `db:"odin_dust" json:"odin_dust,omitempty"`
+	// Fee is the part of Amount charged by the bridge, the rest is converted to OdinAmount
+	Fee       *big.Int `db:"fee" json:"fee,omitempty"`
+	LastError *string  `db:"last_error" json:"last_error,omitempty"`
+	Attempts  int      `db:"attempts" json:"attempts"`
+	// NextAttemptAt postpones claiming of the transfer, which failed transiently
+	NextAttemptAt *time.Time `db:"next_attempt_at" json:"next_attempt_at,omitempty"`
+	// LeaseOwner is the sender instance processing the transfer until LeaseExpiresAt
+	LeaseOwner     *string    `db:"lease_owner" json:"-"`
+	LeaseExpiresAt *time.Time `db:"lease_expires_at" json:"-"`
 }
 
 func (u Transfer) ToMap() map[string]interface{} {
 	result := map[string]interface{}{
-		"address": u.Address,
-		"amount":  u.Amount,
-		"denom":   u.Denom,
-		"status":  u.Status,
-		"user_id": u.UserID,
+		"address":             u.Address,
+		"amount":              u.Amount.String(),
+		"denom":               u.Denom,
+		"status":              u.Status,
+		"user_id":             u.UserID,
+		"sent_at":             u.SentAt,
+		"odin_tx_hash":        u.OdinTxHash,
+		"odin_height":         u.OdinHeight,
+		"odin_timeout_height": u.OdinTimeoutHeight,
+		"odin_amount":         optionalAmount(u.OdinAmount),
+		"odin_denom":          u.OdinDenom,
+		"odin_dust":           optionalDecimal(u.OdinDust),
+		"fee":                 optionalAmount(u.Fee),
+		"last_error":          u.LastError,
+		"attempts":            u.Attempts,
+		"next_attempt_at":     u.NextAttemptAt,
+		"lease_owner":         u.LeaseOwner,
+		"lease_expires_at":    u.LeaseExpiresAt,
 	}
 
 	return result
@@ -23,12 +63,49 @@ func (u Transfer) ToMap() map[string]interface{} {
 
 func (u Transfer) ToReturn() map[string]interface{} {
 	result := map[string]interface{}{
-		"id":      u.ID,
-		"address": u.Address,
-		"amount":  u.Amount,
-		"denom":   u.Denom,
-		"status":  u.Status,
+		"id":              u.ID,
+		"address":         u.Address,
+		"amount":          u.Amount.String(),
+		"denom":           u.Denom,
+		"status":          u.Status,
+		"created_at":      u.CreatedAt,
+		"updated_at":      u.UpdatedAt,
+		"sent_at":         u.SentAt,
+		"odin_tx_hash":    u.OdinTxHash,
+		"odin_height":     u.OdinHeight,
+		"odin_amount":     optionalAmount(u.OdinAmount),
+		"odin_denom":      u.OdinDenom,
+		"odin_dust":       optionalDecimal(u.OdinDust),
+		"fee":             optionalAmount(u.Fee),
+		"last_error":      u.LastError,
+		"attempts":        u.Attempts,
+		"next_attempt_at": u.NextAttemptAt,
 	}
 
 	return result
 }
+
+// ToPublicReturn renders the transfer for its owner, errors of the sender are internal
+func (u Transfer) ToPublicReturn() map[string]interface{} {
+	result := u.ToReturn()
+	delete(result, "last_error")
+	return result
+}
+
+// optionalAmount renders the amount, which may be not set, as a string
+func optionalAmount(amount *big.Int) *string {
+	if amount == nil {
+		return nil
+	}
+	result := amount.String()
+	return &result
+}
+
+// optionalDecimal renders the exact value, which may be not set, as a decimal string
+func optionalDecimal(value *big.Rat) *string {
+	if value == nil {
+		return nil
+	}
+	result := amount.FormatDecimal(value)
+	return &result
+}
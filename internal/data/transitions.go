@@ -0,0 +1,63 @@
+package data
+
+import (
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrTransitionNotAllowed = errors.New("transfer status transition is not allowed")
+
+// transitions defines the state machine of transfer, final statuses have no transitions
+var transitions = map[Status][]Status{
+	StatusNotSent:    {StatusProcessing, StatusCancelled},
+	StatusProcessing: {StatusSent, StatusFailed, StatusNotSent, StatusUnknown, StatusOnHold},
+	StatusUnknown:    {StatusSent, StatusFailed, StatusNotSent},
+	StatusOnHold:     {StatusNotSent, StatusRefunded, StatusCancelled},
+	StatusFailed:     {StatusRefunded},
+	StatusSent:       {},
+	StatusRefunded:   {},
+	StatusCancelled:  {},
+}
+
+// CanTransit checks whether the transfer is allowed to move from one status to another
+func CanTransit(from, to Status) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Final checks whether the status has no transitions
+func (s Status) Final() bool {
+	return len(transitions[s]) == 0
+}
+
+// TransferEvent records a single transition of the transfer
+type TransferEvent struct {
+	ID         int64     `db:"id" json:"id"`
+	TransferID int64     `db:"transfer_id" json:"transfer_id"`
+	FromStatus Status    `db:"from_status" json:"from_status"`
+	ToStatus   Status    `db:"to_status" json:"to_status"`
+	Reason     string    `db:"reason" json:"reason,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+func (e TransferEvent) ToReturn() map[string]interface{} {
+	result := map[string]interface{}{
+		"from_status": e.FromStatus,
+		"to_status":   e.ToStatus,
+		"reason":      e.Reason,
+		"created_at":  e.CreatedAt,
+	}
+
+	return result
+}
+
+// ToPublicReturn renders the event for the owner of the transfer, reasons may hold errors of the sender
+func (e TransferEvent) ToPublicReturn() map[string]interface{} {
+	result := e.ToReturn()
+	delete(result, "reason")
+	return result
+}
@@ -1,16 +1,24 @@
 package data
 
+import "math/big"
+
 type User struct {
-	ID      int64  `db:"id" json:"id"`
-	Address string `db:"address" json:"address"`
-	Amount  string `db:"amount,omitempty" json:"amount,omitempty"`
-	Denom   string `db:"denom,omitempty" json:"denom"`
+	ID      int64    `db:"id" json:"id"`
+	Address string   `db:"address" json:"address"`
+	Amount  *big.Int `db:"amount,omitempty" json:"amount,omitempty"`
+	Denom   string   `db:"denom,omitempty" json:"denom"`
+	// OdinDust accumulates fractions of odin base units dropped by rounding of the user payouts
+	OdinDust *big.Rat `db:"odin_dust" json:"odin_dust"`
+	// MergedInto is the user the duplicate of the same address in another case was merged into
+	MergedInto *int64 `db:"merged_into" json:"-"`
+	// OverClaimed is the amount the duplicates of the address claimed above the balance
+	OverClaimed *big.Int `db:"over_claimed" json:"-"`
 }
 
 func (u User) ToMap() map[string]interface{} {
 	result := map[string]interface{}{
 		"address": u.Address,
-		"amount":  u.Amount,
+		"amount":  u.Amount.String(),
 		"denom":   u.Denom,
 	}
 
@@ -19,10 +27,11 @@ func (u User) ToMap() map[string]interface{} {
 
 func (u User) ToReturn() map[string]interface{} {
 	result := map[string]interface{}{
-		"id":      u.ID,
-		"address": u.Address,
-		"amount":  u.Amount,
-		"denom":   u.Denom,
+		"id":        u.ID,
+		"address":   u.Address,
+		"amount":    u.Amount.String(),
+		"denom":     u.Denom,
+		"odin_dust": optionalDecimal(u.OdinDust),
 	}
 
 	return result
@@ -0,0 +1,189 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+	"math/big"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrTripped is returned while payouts are paused by the breaker
+	ErrTripped = errors.New("outflow breaker is tripped")
+	// ErrMaxTransfer is returned for the transfer above the maximum, which is not approved by the operator,
+	// the breaker is tripped as well
+	ErrMaxTransfer = errors.New("transfer exceeds the maximum")
+)
+
+// Service pauses payouts once the outflow within the rolling window exceeds the caps,
+// payouts are resumed only by the operator. Limits are checked before signing, so concurrent
+// senders of different instances may exceed the cap by the amounts being sent at the moment.
+type Service struct {
+	cfg     config.Config
+	storage postgres.Storage
+}
+
+func New(cfg config.Config, storage postgres.Storage) *Service {
+	return &Service{
+		cfg:     cfg,
+		storage: storage,
+	}
+}
+
+// Usage is the outflow of the denom within the window against its cap
+type Usage struct {
+	Denom string
+	Used  *big.Int
+	Cap   *big.Int
+}
+
+func (u Usage) ToReturn() map[string]interface{} {
+	var limit *string
+	if u.Cap != nil {
+		value := u.Cap.String()
+		limit = &value
+	}
+	return map[string]interface{}{
+		"denom": u.Denom,
+		"used":  u.Used.String(),
+		"cap":   limit,
+	}
+}
+
+// State returns the state of the breaker
+func (s *Service) State(ctx context.Context) (data.CircuitBreaker, error) {
+	return s.storage.Breakers().Get(ctx, data.BreakerOutflow)
+}
+
+// Reset resumes payouts on behalf of the operator, returns false if the breaker is not tripped
+func (s *Service) Reset(ctx context.Context, operator string) (bool, error) {
+	return s.storage.Breakers().Reset(ctx, data.BreakerOutflow, operator)
+}
+
+// Usage returns the outflow within the window in binance tokens and odin denoms
+func (s *Service) Usage(ctx context.Context) (binance, odin []Usage, err error) {
+	binanceUsed, odinUsed, err := s.outflow(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	binance = usages(binanceUsed, s.cfg.BreakerBinanceLimits())
+	odin = usages(odinUsed, s.cfg.BreakerOdinLimits())
+	return binance, odin, nil
+}
+
+// Check returns nil if the payout of the transfer is within limits, otherwise it trips the breaker.
+// The maximum is not applied to the transfer approved by the operator, the caps are.
+func (s *Service) Check(ctx context.Context, transfer data.Transfer, payout sdk.Coin) error {
+	state, err := s.State(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get breaker state")
+	}
+	if state.Tripped() {
+		return ErrTripped
+	}
+
+	binanceLimit := s.cfg.BreakerBinanceLimits()[transfer.Denom]
+	odinLimit := s.cfg.BreakerOdinLimits()[payout.Denom]
+	aboveBinance := exceeds(binanceLimit.MaxTransfer, transfer.Amount)
+	aboveOdin := exceeds(odinLimit.MaxTransfer, payout.Amount.BigInt())
+	if aboveBinance || aboveOdin {
+		approved, err := s.approved(ctx, transfer)
+		switch {
+		case err != nil:
+			return err
+		case approved:
+		case aboveBinance:
+			return s.trip(ctx, ErrMaxTransfer, fmt.Sprintf("transfer %d of %s%s exceeds the maximum %s%s",
+				transfer.ID, transfer.Amount, transfer.Denom, binanceLimit.MaxTransfer, transfer.Denom))
+		default:
+			return s.trip(ctx, ErrMaxTransfer, fmt.Sprintf("payout of transfer %d of %s exceeds the maximum %s%s",
+				transfer.ID, payout, odinLimit.MaxTransfer, payout.Denom))
+		}
+	}
+
+	binanceUsed, odinUsed, err := s.outflow(ctx)
+	if err != nil {
+		return err
+	}
+	if used := sum(binanceUsed[transfer.Denom], transfer.Amount); exceeds(binanceLimit.Cap, used) {
+		return s.trip(ctx, ErrTripped, fmt.Sprintf("outflow of %s%s within %s exceeds the cap %s%s",
+			used, transfer.Denom, s.cfg.BreakerWindow(), binanceLimit.Cap, transfer.Denom))
+	}
+	if used := sum(odinUsed[payout.Denom], payout.Amount.BigInt()); exceeds(odinLimit.Cap, used) {
+		return s.trip(ctx, ErrTripped, fmt.Sprintf("outflow of %s%s within %s exceeds the cap %s%s",
+			used, payout.Denom, s.cfg.BreakerWindow(), odinLimit.Cap, payout.Denom))
+	}
+	return nil
+}
+
+// approved checks whether the last decision on the transfer is the approval by the operator
+func (s *Service) approved(ctx context.Context, transfer data.Transfer) (bool, error) {
+	reviews, err := s.storage.Reviews().SelectReviews(ctx, transfer.ID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to select transfer reviews")
+	}
+	return len(reviews) > 0 && reviews[len(reviews)-1].Decision == data.ReviewApprove, nil
+}
+
+func (s *Service) trip(ctx context.Context, cause error, reason string) error {
+	if _, err := s.storage.Breakers().Trip(ctx, data.BreakerOutflow, reason); err != nil {
+		return errors.Wrap(err, "failed to trip breaker")
+	}
+	return errors.Wrap(cause, reason)
+}
+
+// outflow returns amounts paid out within the window by binance tokens and odin denoms
+func (s *Service) outflow(ctx context.Context) (binance, odin map[string]*big.Int, err error) {
+	since := time.Now().UTC().Add(-s.cfg.BreakerWindow())
+	outflows, err := s.storage.Transfers().SelectOutflow(ctx, since)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to select outflow")
+	}
+
+	binance, odin = make(map[string]*big.Int), make(map[string]*big.Int)
+	for _, outflow := range outflows {
+		binance[outflow.Denom] = sum(binance[outflow.Denom], outflow.Amount)
+		odin[outflow.OdinDenom] = sum(odin[outflow.OdinDenom], outflow.OdinAmount)
+	}
+	return binance, odin, nil
+}
+
+// usages lists the denoms which are limited or already used
+func usages(used map[string]*big.Int, limits map[string]config.Limit) []Usage {
+	result := make([]Usage, 0, len(limits))
+	for denom, limit := range limits {
+		result = append(result, Usage{Denom: denom, Used: sum(used[denom], nil), Cap: limit.Cap})
+	}
+	for denom, amount := range used {
+		if _, ok := limits[denom]; !ok {
+			result = append(result, Usage{Denom: denom, Used: amount})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Denom < result[j].Denom
+	})
+	return result
+}
+
+func sum(a, b *big.Int) *big.Int {
+	result := new(big.Int)
+	if a != nil {
+		result.Add(result, a)
+	}
+	if b != nil {
+		result.Add(result, b)
+	}
+	return result
+}
+
+// exceeds checks whether the amount is above the limit, nil limit is not applied
+func exceeds(limit, amount *big.Int) bool {
+	return limit != nil && amount != nil && amount.Cmp(limit) > 0
+}
@@ -0,0 +1,124 @@
+package fees
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+// ErrNotCovered is returned when the transfer amount does not exceed the fee
+var ErrNotCovered = errors.New("amount does not cover the fee")
+
+// Quote splits the transfer amount between the bridge fee and the net amount converted to the payout
+type Quote struct {
+	Denom  string
+	Amount *big.Int
+	Fee    *big.Int
+	Net    *big.Int
+}
+
+func (q Quote) ToReturn() map[string]interface{} {
+	result := map[string]interface{}{
+		"denom":  q.Denom,
+		"amount": q.Amount.String(),
+		"fee":    q.Fee.String(),
+		"net":    q.Net.String(),
+	}
+
+	return result
+}
+
+// Service charges fees by the policies of tokens and sweeps the accumulated ones
+type Service struct {
+	cfg     config.Config
+	storage postgres.Storage
+}
+
+func New(cfg config.Config, storage postgres.Storage) *Service {
+	return &Service{
+		cfg:     cfg,
+		storage: storage,
+	}
+}
+
+// Quote applies the fee policy of the token to the amount, returns ErrNotCovered with the quote if nothing is left to pay out
+func (s *Service) Quote(denom string, amount *big.Int) (Quote, error) {
+	quote := Quote{
+		Denom:  denom,
+		Amount: new(big.Int).Set(amount),
+		Fee:    new(big.Int),
+	}
+	if policy, ok := s.cfg.Fee(denom); ok {
+		quote.Fee = charge(policy, amount)
+	}
+
+	quote.Net = new(big.Int).Sub(amount, quote.Fee)
+	if quote.Net.Sign() <= 0 {
+		return quote, ErrNotCovered
+	}
+	return quote, nil
+}
+
+// charge is the fixed part and the percentage of the amount rounded down, bounded by min and max
+func charge(policy config.FeePolicy, amount *big.Int) *big.Int {
+	fee := new(big.Int)
+	if policy.Fixed != nil {
+		fee.Add(fee, policy.Fixed)
+	}
+	if policy.Percent != nil {
+		percentage := new(big.Int).Mul(amount, policy.Percent.Num())
+		percentage.Quo(percentage, new(big.Int).Mul(policy.Percent.Denom(), big.NewInt(100)))
+		fee.Add(fee, percentage)
+	}
+
+	if policy.Min != nil && fee.Cmp(policy.Min) < 0 {
+		fee.Set(policy.Min)
+	}
+	if policy.Max != nil && fee.Cmp(policy.Max) > 0 {
+		fee.Set(policy.Max)
+	}
+	return fee
+}
+
+// Balances returns fees accumulated by denoms
+func (s *Service) Balances(ctx context.Context) ([]data.AccountBalance, error) {
+	balances, err := s.storage.Ledger().SelectBalances(ctx, data.AccountFees)
+	return balances, errors.Wrap(err, "failed to select fees balances")
+}
+
+// Sweep moves all the accumulated fees of the denom out of the bridge on behalf of the operator,
+// returns the swept amount, which is zero if there is nothing to sweep
+func (s *Service) Sweep(ctx context.Context, denom, operator, memo string) (*big.Int, error) {
+	swept := new(big.Int)
+	err := s.storage.Transaction(ctx, func(tx postgres.Storage) error {
+		// concurrent sweeps would read the same balance and sweep it twice
+		if err := tx.Lock(ctx, fmt.Sprintf("fees:%s", denom)); err != nil {
+			return errors.Wrap(err, "failed to lock fees")
+		}
+
+		balances, err := tx.Ledger().SelectBalances(ctx, data.AccountFees)
+		if err != nil {
+			return errors.Wrap(err, "failed to select fees balances")
+		}
+		for _, balance := range balances {
+			if balance.Denom == denom {
+				swept.Set(balance.Amount)
+			}
+		}
+		if swept.Sign() <= 0 {
+			swept.SetInt64(0)
+			return nil
+		}
+
+		return ledger.New(tx).SweepFees(ctx, denom, swept, fmt.Sprintf("swept by %s: %s", operator, memo))
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to sweep %s fees", denom)
+	}
+	return swept, nil
+}
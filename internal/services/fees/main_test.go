@@ -0,0 +1,28 @@
+package fees
+
+import (
+	"github.com/bsc-bridge-svc/internal/config"
+	"math/big"
+	"testing"
+)
+
+func TestCharge(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy config.FeePolicy
+		amount int64
+		fee    int64
+	}{
+		{"free", config.FeePolicy{}, 1_000, 0},
+		{"fixed", config.FeePolicy{Fixed: big.NewInt(7)}, 1_000, 7},
+		{"percent rounded down", config.FeePolicy{Percent: big.NewRat(25, 100)}, 1_999, 4},
+		{"fixed and percent", config.FeePolicy{Fixed: big.NewInt(7), Percent: big.NewRat(1, 1)}, 1_000, 17},
+		{"min", config.FeePolicy{Percent: big.NewRat(1, 1), Min: big.NewInt(50)}, 1_000, 50},
+		{"max", config.FeePolicy{Percent: big.NewRat(10, 1), Max: big.NewInt(50)}, 1_000, 50},
+	}
+	for _, c := range cases {
+		if fee := charge(c.policy, big.NewInt(c.amount)); fee.Int64() != c.fee {
+			t.Errorf("%s: expected fee %d, got %s", c.name, c.fee, fee)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"hash/fnv"
+	"sync"
+)
+
+// Service elects the single replica running singleton jobs, it holds a postgres advisory lock on a dedicated session.
+// The lock is released by postgres once the session drops, so another replica takes over on its next campaign.
+type Service struct {
+	db  *sql.DB
+	key int64
+	log *logrus.Logger
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// New creates the election of the lock named name, every replica is the leader if the in-memory storage is used
+func New(cfg config.Config, name string) *Service {
+	var db *sql.DB
+	if !cfg.DBInMemory() {
+		db = cfg.DB()
+	}
+	return newService(db, name, cfg.Logger())
+}
+
+func newService(db *sql.DB, name string, log *logrus.Logger) *Service {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(name))
+	return &Service{
+		db:  db,
+		key: int64(hash.Sum64()),
+		log: log,
+	}
+}
+
+// Campaign checks that the leader still holds the lock or tries to acquire it otherwise
+func (s *Service) Campaign(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+
+	if s.conn != nil {
+		if err := s.conn.PingContext(ctx); err == nil {
+			return nil
+		}
+		s.log.WithField("key", s.key).Warn("Leader session dropped, stepping down")
+		s.drop()
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to open session")
+	}
+
+	acquired := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", s.key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return errors.Wrap(err, "failed to try advisory lock")
+	}
+	if !acquired {
+		return conn.Close()
+	}
+
+	s.log.WithField("key", s.key).Info("Elected as the leader")
+	s.conn = conn
+	return nil
+}
+
+// IsLeader reports whether the replica holds the lock
+func (s *Service) IsLeader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db == nil || s.conn != nil
+}
+
+// Only wraps the singleton job, so it is run by the leader only
+func (s *Service) Only(run func() error) func() error {
+	return func() error {
+		if !s.IsLeader() {
+			return nil
+		}
+		return run()
+	}
+}
+
+// Resign releases the lock, so another replica may take over without waiting for the session to drop
+func (s *Service) Resign(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	defer s.drop()
+
+	if _, err := s.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", s.key); err != nil {
+		return errors.Wrap(err, "failed to release advisory lock")
+	}
+	s.log.WithField("key", s.key).Info("Resigned from the leadership")
+	return nil
+}
+
+// drop closes the session of the leader, must be called with mu held
+func (s *Service) drop() {
+	if err := s.conn.Close(); err != nil {
+		s.log.WithError(err).Debug("failed to close leader session")
+	}
+	s.conn = nil
+}
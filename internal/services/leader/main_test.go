@@ -0,0 +1,163 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// lockServer imitates session level advisory locks of postgres, locks are released once the session drops
+type lockServer struct {
+	mu       sync.Mutex
+	sessions int
+	owners   map[int64]int
+	dropped  map[int]bool
+}
+
+func (s *lockServer) Open(string) (driver.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions++
+	return &lockSession{server: s, id: s.sessions}, nil
+}
+
+// kill drops the session holding the lock
+func (s *lockServer) kill(key int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped[s.owners[key]] = true
+	delete(s.owners, key)
+}
+
+type lockSession struct {
+	server *lockServer
+	id     int
+}
+
+func (c *lockSession) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *lockSession) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *lockSession) Close() error {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	for key, owner := range c.server.owners {
+		if owner == c.id {
+			delete(c.server.owners, key)
+		}
+	}
+	return nil
+}
+
+func (c *lockSession) Ping(context.Context) error {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	if c.server.dropped[c.id] {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *lockSession) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	key := args[0].Value.(int64)
+	acquired := false
+	if strings.Contains(query, "pg_try_advisory_lock") {
+		owner, ok := c.server.owners[key]
+		if !ok {
+			c.server.owners[key] = c.id
+		}
+		acquired = !ok || owner == c.id
+	}
+	return &boolRows{value: acquired}, nil
+}
+
+func (c *lockSession) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	key := args[0].Value.(int64)
+	if strings.Contains(query, "pg_advisory_unlock") && c.server.owners[key] == c.id {
+		delete(c.server.owners, key)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+type boolRows struct {
+	value bool
+	read  bool
+}
+
+func (r *boolRows) Columns() []string {
+	return []string{"locked"}
+}
+
+func (r *boolRows) Close() error {
+	return nil
+}
+
+func (r *boolRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = r.value
+	return nil
+}
+
+func TestService_Failover(t *testing.T) {
+	server := &lockServer{owners: make(map[int64]int), dropped: make(map[int]bool)}
+	sql.Register("leader", server)
+	db, err := sql.Open("leader", "")
+	if err != nil {
+		t.Fatalf("failed to open db: %s", err)
+	}
+	defer db.Close()
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	ctx := context.Background()
+	first, second := newService(db, "jobs", log), newService(db, "jobs", log)
+
+	for _, replica := range []*Service{first, second} {
+		if err := replica.Campaign(ctx); err != nil {
+			t.Fatalf("failed to campaign: %s", err)
+		}
+	}
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("expected the first replica to be the only leader")
+	}
+
+	// the session of the leader drops, postgres releases its lock
+	server.kill(first.key)
+	for _, replica := range []*Service{second, first} {
+		if err := replica.Campaign(ctx); err != nil {
+			t.Fatalf("failed to campaign: %s", err)
+		}
+	}
+	if first.IsLeader() || !second.IsLeader() {
+		t.Fatalf("expected the second replica to take over")
+	}
+
+	if err := second.Resign(ctx); err != nil {
+		t.Fatalf("failed to resign: %s", err)
+	}
+	if err := first.Campaign(ctx); err != nil {
+		t.Fatalf("failed to campaign: %s", err)
+	}
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("expected the first replica to take over after resignation")
+	}
+}
@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+// Service posts balanced journals for every balance movement and keeps the cached balances of users in sync
+type Service struct {
+	storage postgres.Storage
+}
+
+func New(storage postgres.Storage) *Service {
+	return &Service{
+		storage: storage,
+	}
+}
+
+// Open credits the user with the balance snapshotted from the binance smart chain
+func (s *Service) Open(ctx context.Context, user data.User, amount *big.Int) error {
+	return s.post(ctx, data.LedgerJournal{
+		Kind:   data.JournalOpening,
+		UserID: &user.ID,
+		Entries: []data.LedgerEntry{
+			debit(data.AccountBscHoldings, nil, user.Denom, amount),
+			credit(data.AccountUser, &user.ID, user.Denom, amount),
+		},
+	})
+}
+
+// Claim moves the amount of created transfer from the user to pending
+func (s *Service) Claim(ctx context.Context, transfer data.Transfer) error {
+	return s.post(ctx, data.LedgerJournal{
+		Kind:       data.JournalClaim,
+		UserID:     &transfer.UserID,
+		TransferID: &transfer.ID,
+		Entries: []data.LedgerEntry{
+			debit(data.AccountUser, &transfer.UserID, transfer.Denom, transfer.Amount),
+			credit(data.AccountPending, nil, transfer.Denom, transfer.Amount),
+		},
+	})
+}
+
+// Refund returns the amount of failed transfer from pending to the user
+func (s *Service) Refund(ctx context.Context, transfer data.Transfer) error {
+	return s.post(ctx, data.LedgerJournal{
+		Kind:       data.JournalRefund,
+		UserID:     &transfer.UserID,
+		TransferID: &transfer.ID,
+		Entries: []data.LedgerEntry{
+			debit(data.AccountPending, nil, transfer.Denom, transfer.Amount),
+			credit(data.AccountUser, &transfer.UserID, transfer.Denom, transfer.Amount),
+		},
+	})
+}
+
+// Cancel returns the amount of the transfer cancelled by the user from pending to the user
+func (s *Service) Cancel(ctx context.Context, transfer data.Transfer) error {
+	return s.post(ctx, data.LedgerJournal{
+		Kind:       data.JournalCancel,
+		UserID:     &transfer.UserID,
+		TransferID: &transfer.ID,
+		Entries: []data.LedgerEntry{
+			debit(data.AccountPending, nil, transfer.Denom, transfer.Amount),
+			credit(data.AccountUser, &transfer.UserID, transfer.Denom, transfer.Amount),
+		},
+	})
+}
+
+// Payout moves the amount of sent transfer from pending to the odin treasury, the fee charged from the transfer is kept in fees
+func (s *Service) Payout(ctx context.Context, transfer data.Transfer) error {
+	entries := []data.LedgerEntry{
+		debit(data.AccountPending, nil, transfer.Denom, transfer.Amount),
+	}
+	if transfer.Fee == nil || transfer.Fee.Sign() == 0 {
+		entries = append(entries, credit(data.AccountTreasury, nil, transfer.Denom, transfer.Amount))
+	} else {
+		entries = append(entries,
+			credit(data.AccountTreasury, nil, transfer.Denom, new(big.Int).Sub(transfer.Amount, transfer.Fee)),
+			credit(data.AccountFees, nil, transfer.Denom, transfer.Fee),
+		)
+	}
+
+	return s.post(ctx, data.LedgerJournal{
+		Kind:       data.JournalPayout,
+		UserID:     &transfer.UserID,
+		TransferID: &transfer.ID,
+		Entries:    entries,
+	})
+}
+
+// SweepFees moves the amount of accumulated fees out of the bridge
+func (s *Service) SweepFees(ctx context.Context, denom string, amount *big.Int, memo string) error {
+	return s.post(ctx, data.LedgerJournal{
+		Kind: data.JournalFeeSweep,
+		Memo: memo,
+		Entries: []data.LedgerEntry{
+			debit(data.AccountFees, nil, denom, amount),
+			credit(data.AccountSweptFees, nil, denom, amount),
+		},
+	})
+}
+
+// Adjust manually changes the balance of the user by delta, which may be negative
+func (s *Service) Adjust(ctx context.Context, user data.User, delta *big.Int, memo string) error {
+	if delta.Sign() == 0 {
+		return errors.New("adjustment amount must not be zero")
+	}
+
+	amount := new(big.Int).Abs(delta)
+	entries := []data.LedgerEntry{
+		debit(data.AccountAdjustments, nil, user.Denom, amount),
+		credit(data.AccountUser, &user.ID, user.Denom, amount),
+	}
+	if delta.Sign() < 0 {
+		entries = []data.LedgerEntry{
+			debit(data.AccountUser, &user.ID, user.Denom, amount),
+			credit(data.AccountAdjustments, nil, user.Denom, amount),
+		}
+	}
+
+	return s.post(ctx, data.LedgerJournal{
+		Kind:    data.JournalAdjustment,
+		UserID:  &user.ID,
+		Memo:    memo,
+		Entries: entries,
+	})
+}
+
+// Rebuild recalculates the cached balances of all users from the ledger
+func (s *Service) Rebuild(ctx context.Context) (int64, error) {
+	var changed int64
+	err := s.storage.Transaction(ctx, func(tx postgres.Storage) error {
+		var err error
+		changed, err = tx.Ledger().RebuildBalances(ctx)
+		return err
+	})
+	return changed, err
+}
+
+// post appends the journal and applies its user entries to the cached balances
+func (s *Service) post(ctx context.Context, journal data.LedgerJournal) error {
+	return s.storage.Transaction(ctx, func(tx postgres.Storage) error {
+		if _, err := tx.Ledger().CreateJournal(ctx, journal); err != nil {
+			return errors.Wrapf(err, "failed to post %s journal", journal.Kind)
+		}
+
+		for _, entry := range journal.Entries {
+			if entry.Account != data.AccountUser {
+				continue
+			}
+			delta := new(big.Int).Sub(entry.Credit, entry.Debit)
+			if err := tx.Users().AddAmount(ctx, *entry.UserID, delta); err != nil {
+				return errors.Wrap(err, "failed to update user balance")
+			}
+		}
+		return nil
+	})
+}
+
+func debit(account data.Account, userID *int64, denom string, amount *big.Int) data.LedgerEntry {
+	return data.LedgerEntry{
+		Account: account,
+		UserID:  userID,
+		Denom:   denom,
+		Debit:   amount,
+		Credit:  new(big.Int),
+	}
+}
+
+func credit(account data.Account, userID *int64, denom string, amount *big.Int) data.LedgerEntry {
+	return data.LedgerEntry{
+		Account: account,
+		UserID:  userID,
+		Denom:   denom,
+		Debit:   new(big.Int),
+		Credit:  amount,
+	}
+}
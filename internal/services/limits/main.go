@@ -0,0 +1,196 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/pkg/errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// codes of the violated limits, sizes of a single claim are validated with the request
+const (
+	CodeMaxTotal = "max_total"
+	CodeCooldown = "cooldown"
+)
+
+// scopes of the limits
+const (
+	ScopeBinanceAddress = "binance_address"
+	ScopeOdinAddress    = "odin_address"
+)
+
+// counted are the statuses of transfers, which amounts count towards the total,
+// refunded and cancelled ones are returned to the user and failed ones are about to be
+var counted = []data.Status{
+	data.StatusNotSent,
+	data.StatusProcessing,
+	data.StatusUnknown,
+	data.StatusOnHold,
+	data.StatusSent,
+}
+
+// Violation describes the limit the claim violates
+type Violation struct {
+	Code    string
+	Scope   string
+	Address string
+	// Limit is the violated amount, nil for the cooldown
+	Limit *big.Int
+	// RetryAt is the time the same claim is allowed at, nil if it is never allowed
+	RetryAt *time.Time
+	Message string
+}
+
+func (v Violation) Error() string {
+	return v.Message
+}
+
+func (v Violation) ToReturn() map[string]interface{} {
+	var limit *string
+	if v.Limit != nil {
+		value := v.Limit.String()
+		limit = &value
+	}
+	return map[string]interface{}{
+		"code":     v.Code,
+		"scope":    v.Scope,
+		"address":  v.Address,
+		"limit":    limit,
+		"retry_at": v.RetryAt,
+		"detail":   v.Message,
+	}
+}
+
+// Service applies limits of claims per binance and odin address
+type Service struct {
+	cfg     config.Config
+	storage postgres.Storage
+}
+
+func New(cfg config.Config, storage postgres.Storage) *Service {
+	return &Service{
+		cfg:     cfg,
+		storage: storage,
+	}
+}
+
+// Check returns the violated limit of the transfer claimed by the user, nil if it is allowed.
+// It must be called in the transaction creating the transfer: the addresses stay locked until it ends,
+// so concurrent claims of the same address are checked one after another.
+func (s *Service) Check(ctx context.Context, transfer data.Transfer, user data.User) (*Violation, error) {
+	binanceLimit, odinLimit := s.cfg.AddressLimits(transfer.Denom)
+
+	err := s.storage.Lock(ctx,
+		fmt.Sprintf("limits:%s:%s", ScopeBinanceAddress, strings.ToLower(user.Address)),
+		fmt.Sprintf("limits:%s:%s", ScopeOdinAddress, transfer.Address),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock addresses")
+	}
+
+	now := time.Now().UTC()
+	scopes := []struct {
+		scope   string
+		address string
+		limit   config.AddressLimit
+		query   postgres.Transfers
+	}{
+		{ScopeBinanceAddress, user.Address, binanceLimit, s.storage.Transfers().FilterByUserAddress(user.Address)},
+		{ScopeOdinAddress, transfer.Address, odinLimit, s.storage.Transfers().FilterByAddress(transfer.Address)},
+	}
+	for _, scope := range scopes {
+		var recent []data.Transfer
+		if period := lookback(scope.limit); period > 0 {
+			since := now.Add(-period)
+			recent, err = scope.query.FilterByDenom(transfer.Denom).FilterByCreatedAt(&since, nil).Select(ctx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to select recent transfers of %s", scope.scope)
+			}
+		}
+
+		violation := check(scope.limit, transfer.Amount, recent, now)
+		if violation != nil {
+			violation.Scope, violation.Address = scope.scope, scope.address
+			violation.Message = fmt.Sprintf("%s of %s: %s", scope.scope, scope.address, violation.Message)
+			return violation, nil
+		}
+	}
+	return nil, nil
+}
+
+// lookback is how old transfers affect the limit
+func lookback(limit config.AddressLimit) time.Duration {
+	period := limit.Cooldown
+	if limit.MaxTotal != nil && limit.Window > period {
+		period = limit.Window
+	}
+	return period
+}
+
+func check(limit config.AddressLimit, amount *big.Int, recent []data.Transfer, now time.Time) *Violation {
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].CreatedAt.Before(recent[j].CreatedAt)
+	})
+
+	if limit.Cooldown > 0 && len(recent) > 0 {
+		retryAt := recent[len(recent)-1].CreatedAt.Add(limit.Cooldown)
+		if now.Before(retryAt) {
+			return &Violation{Code: CodeCooldown, RetryAt: &retryAt, Message: fmt.Sprintf("claims are allowed once in %s", limit.Cooldown)}
+		}
+	}
+
+	if limit.MaxTotal != nil && limit.Window > 0 {
+		return checkTotal(limit, amount, recent, now)
+	}
+	return nil
+}
+
+// checkTotal checks the amount claimed within the window, the claim is retried once enough of the recent
+// transfers leave the window
+func checkTotal(limit config.AddressLimit, amount *big.Int, recent []data.Transfer, now time.Time) *Violation {
+	windowed := make([]data.Transfer, 0, len(recent))
+	total := new(big.Int).Set(amount)
+	for _, transfer := range recent {
+		if transfer.CreatedAt.Before(now.Add(-limit.Window)) || !isCounted(transfer.Status) {
+			continue
+		}
+		windowed = append(windowed, transfer)
+		total.Add(total, transfer.Amount)
+	}
+	if total.Cmp(limit.MaxTotal) <= 0 {
+		return nil
+	}
+
+	violation := &Violation{
+		Code:    CodeMaxTotal,
+		Limit:   limit.MaxTotal,
+		Message: fmt.Sprintf("claims within %s are above the maximum %s", limit.Window, limit.MaxTotal),
+	}
+	if amount.Cmp(limit.MaxTotal) > 0 {
+		return violation
+	}
+	for _, transfer := range windowed {
+		total.Sub(total, transfer.Amount)
+		if total.Cmp(limit.MaxTotal) <= 0 {
+			retryAt := transfer.CreatedAt.Add(limit.Window)
+			violation.RetryAt = &retryAt
+			break
+		}
+	}
+	return violation
+}
+
+func isCounted(status data.Status) bool {
+	for _, known := range counted {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
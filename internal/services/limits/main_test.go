@@ -0,0 +1,39 @@
+package limits
+
+import (
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCheckTotal(t *testing.T) {
+	now := time.Now().UTC()
+	limit := config.AddressLimit{MaxTotal: big.NewInt(10), Window: 24 * time.Hour}
+	recent := []data.Transfer{
+		{Amount: big.NewInt(4), Status: data.StatusSent, CreatedAt: now.Add(-20 * time.Hour)},
+		{Amount: big.NewInt(100), Status: data.StatusRefunded, CreatedAt: now.Add(-15 * time.Hour)},
+		{Amount: big.NewInt(3), Status: data.StatusNotSent, CreatedAt: now.Add(-10 * time.Hour)},
+		{Amount: big.NewInt(50), Status: data.StatusSent, CreatedAt: now.Add(-30 * time.Hour)},
+	}
+
+	if violation := check(limit, big.NewInt(3), recent, now); violation != nil {
+		t.Fatalf("expected claim within the total to be allowed, got %+v", violation)
+	}
+
+	// 4 + 3 + 5 is above the total, the claim is allowed once the oldest counted transfer leaves the window
+	violation := check(limit, big.NewInt(5), recent, now)
+	if violation == nil || violation.Code != CodeMaxTotal || violation.RetryAt == nil {
+		t.Fatalf("expected total to be exceeded with retry, got %+v", violation)
+	}
+	if expected := now.Add(4 * time.Hour); !violation.RetryAt.Equal(expected) {
+		t.Fatalf("expected retry at %s, got %s", expected, violation.RetryAt)
+	}
+
+	// the claim above the total is never allowed
+	violation = check(limit, big.NewInt(11), recent, now)
+	if violation == nil || violation.Code != CodeMaxTotal || violation.RetryAt != nil {
+		t.Fatalf("expected total to be exceeded without retry, got %+v", violation)
+	}
+}
@@ -0,0 +1,68 @@
+package pause
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownTarget = errors.New("unknown pause target")
+
+// Service pauses and resumes claims and payouts on behalf of operators
+type Service struct {
+	storage postgres.Storage
+}
+
+func New(storage postgres.Storage) *Service {
+	return &Service{
+		storage: storage,
+	}
+}
+
+// Pause pauses the target, returns false if it is already paused
+func (s *Service) Pause(ctx context.Context, target, operator, reason string) (bool, error) {
+	if err := validTarget(target); err != nil {
+		return false, err
+	}
+	paused, err := s.storage.Breakers().Trip(ctx, target, fmt.Sprintf("paused by %s: %s", operator, reason))
+	return paused, errors.Wrapf(err, "failed to pause %s", target)
+}
+
+// Resume resumes the target, returns false if it is not paused
+func (s *Service) Resume(ctx context.Context, target, operator string) (bool, error) {
+	if err := validTarget(target); err != nil {
+		return false, err
+	}
+	resumed, err := s.storage.Breakers().Reset(ctx, target, operator)
+	return resumed, errors.Wrapf(err, "failed to resume %s", target)
+}
+
+// State returns the pause of the target, the target is paused while the pause is tripped
+func (s *Service) State(ctx context.Context, target string) (data.CircuitBreaker, error) {
+	state, err := s.storage.Breakers().Get(ctx, target)
+	return state, errors.Wrapf(err, "failed to get pause of %s", target)
+}
+
+// States returns pauses of all the targets
+func (s *Service) States(ctx context.Context) ([]data.CircuitBreaker, error) {
+	result := make([]data.CircuitBreaker, 0, len(data.PauseTargets))
+	for _, target := range data.PauseTargets {
+		state, err := s.State(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, state)
+	}
+	return result, nil
+}
+
+func validTarget(target string) error {
+	for _, known := range data.PauseTargets {
+		if target == known {
+			return nil
+		}
+	}
+	return errors.Wrap(ErrUnknownTarget, target)
+}
@@ -0,0 +1,138 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+var (
+	ErrNotFound = errors.New("transfer not found")
+	ErrNotHeld  = errors.New("transfer is not on hold")
+)
+
+// Service applies the manual review policy and records the decisions on held transfers
+type Service struct {
+	cfg     config.Config
+	storage postgres.Storage
+}
+
+func New(cfg config.Config, storage postgres.Storage) *Service {
+	return &Service{
+		cfg:     cfg,
+		storage: storage,
+	}
+}
+
+// Check returns the reason to hold the transfer of the user for the manual review, empty if it may be sent
+func (s *Service) Check(transfer data.Transfer, user data.User) string {
+	if user.OverClaimed != nil {
+		return fmt.Sprintf("binance address %s over-claimed %s%s before deduplication", user.Address, user.OverClaimed, user.Denom)
+	}
+	if s.cfg.ReviewFlagged(user.Address) {
+		return fmt.Sprintf("binance address %s is flagged", user.Address)
+	}
+	if s.cfg.ReviewFlagged(transfer.Address) {
+		return fmt.Sprintf("odin address %s is flagged", transfer.Address)
+	}
+
+	threshold, ok := s.cfg.ReviewThreshold(transfer.Denom)
+	if ok && transfer.Amount.Cmp(threshold) > 0 {
+		return fmt.Sprintf("amount exceeds the review threshold %s%s", threshold, transfer.Denom)
+	}
+	return ""
+}
+
+// Hold records the reason the created transfer is held for
+func (s *Service) Hold(ctx context.Context, transfer data.Transfer, reason string) error {
+	_, err := s.storage.Reviews().CreateReview(ctx, data.TransferReview{
+		TransferID: transfer.ID,
+		Decision:   data.ReviewHold,
+		Operator:   data.OperatorSystem,
+		Reason:     reason,
+	})
+	return errors.Wrap(err, "failed to record hold")
+}
+
+// Held returns the page of transfers waiting for the review
+func (s *Service) Held(ctx context.Context, page data.PageParams) ([]data.Transfer, error) {
+	transfers, err := s.storage.Transfers().FilterByStatus(data.StatusOnHold).Page(page).Select(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select held transfers")
+	}
+	return transfers, nil
+}
+
+// Approve returns the held transfer to the queue, so it is sent
+func (s *Service) Approve(ctx context.Context, id int64, operator, reason string) (*data.Transfer, error) {
+	return s.decide(ctx, id, data.ReviewApprove, operator, reason, func(tx postgres.Storage, transfer data.Transfer) error {
+		return tx.Transfers().Transit(ctx, transfer, data.StatusNotSent, "approved by "+operator)
+	})
+}
+
+// Reject refunds the held transfer to the user
+func (s *Service) Reject(ctx context.Context, id int64, operator, reason string) (*data.Transfer, error) {
+	return s.decide(ctx, id, data.ReviewReject, operator, reason, func(tx postgres.Storage, transfer data.Transfer) error {
+		if err := tx.Transfers().Transit(ctx, transfer, data.StatusRefunded, "rejected by "+operator); err != nil {
+			return err
+		}
+		return ledger.New(tx).Refund(ctx, transfer)
+	})
+}
+
+// decide applies the decision to the held transfer and records it in the same transaction
+func (s *Service) decide(
+	ctx context.Context,
+	id int64,
+	decision data.ReviewDecision,
+	operator, reason string,
+	apply func(postgres.Storage, data.Transfer) error,
+) (*data.Transfer, error) {
+	if strings.TrimSpace(operator) == "" || strings.TrimSpace(reason) == "" {
+		return nil, errors.New("operator and reason of the decision are required")
+	}
+
+	var result *data.Transfer
+	err := s.storage.Transaction(ctx, func(tx postgres.Storage) error {
+		transfer, err := tx.Transfers().Get(ctx, id)
+		if err != nil {
+			return errors.Wrap(err, "failed to get transfer")
+		}
+		if transfer == nil {
+			return ErrNotFound
+		}
+		if transfer.Status != data.StatusOnHold {
+			return ErrNotHeld
+		}
+
+		if err := apply(tx, *transfer); err != nil {
+			// the decision is made concurrently by another operator
+			if errors.Is(err, postgres.ErrStatusConflict) {
+				return ErrNotHeld
+			}
+			return errors.Wrapf(err, "failed to %s transfer", decision)
+		}
+
+		_, err = tx.Reviews().CreateReview(ctx, data.TransferReview{
+			TransferID: transfer.ID,
+			Decision:   decision,
+			Operator:   operator,
+			Reason:     reason,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to record decision")
+		}
+
+		result, err = tx.Transfers().Get(ctx, id)
+		return errors.Wrap(err, "failed to get reviewed transfer")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
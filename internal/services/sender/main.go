@@ -2,149 +2,496 @@ package sender
 
 import (
 	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/amount"
 	"github.com/bsc-bridge-svc/internal/config"
 	"github.com/bsc-bridge-svc/internal/data"
 	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/breaker"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/bsc-bridge-svc/internal/services/pause"
+	"github.com/bsc-bridge-svc/internal/services/review"
 	"github.com/bsc-bridge-svc/odin"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"math/big"
+	"os"
+	"sync"
+	"time"
 )
 
 type Service struct {
 	cfg       config.Config
 	ctx       context.Context
 	log       *logrus.Logger
+	storage   postgres.Storage
 	transfers postgres.Transfers
-	users     postgres.Users
 	odin      odin.Client
+	breaker   *breaker.Service
+	// owner identifies the instance in leases of the claimed transfers
+	owner string
+	// signer serializes signing, as transactions of the same account take consecutive sequences
+	signer sync.Mutex
 }
 
-func New(cfg config.Config, ctx context.Context) *Service {
+func New(cfg config.Config, ctx context.Context, storage postgres.Storage) *Service {
 	return &Service{
 		cfg:       cfg,
 		ctx:       ctx,
 		log:       cfg.Logger(),
-		transfers: postgres.NewTransfers(cfg),
-		users:     postgres.NewUsers(cfg),
+		storage:   storage,
+		transfers: storage.Transfers(),
 		odin:      odin.New(ctx, cfg).WithSigner(),
+		breaker:   breaker.New(cfg, storage),
+		owner:     leaseOwner(),
 	}
 }
 
+// leaseOwner is unique for every running instance, even for the ones on the same host
+func leaseOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano())
+}
+
+// StatusTransfer moves the transfer from its current status to another one, stores details of the transfer
 func (s *Service) StatusTransfer(transfer data.Transfer, status data.Status) error {
-	transfer.Status = status
-	err := s.transfers.UpdateTransfer(transfer)
+	reason := ""
+	if transfer.LastError != nil {
+		reason = *transfer.LastError
+	}
+
+	err := s.transfers.Transit(s.ctx, transfer, status, reason)
 	if err != nil {
-		return errors.Wrap(err, "failed to update transfer")
+		return errors.Wrapf(err, "failed to mark transfer %s", status)
 	}
 	return nil
 }
 
-// Send todo: refactor to several function
+// FailTransfer marks transfer as failed and stores the reason of failure
+func (s *Service) FailTransfer(transfer data.Transfer, reason error) error {
+	lastError := reason.Error()
+	transfer.LastError = &lastError
+	return s.StatusTransfer(transfer, data.StatusFailed)
+}
+
+// ReleaseTransfer returns the transfer to the queue, so it is sent on the next run
+func (s *Service) ReleaseTransfer(transfer data.Transfer, reason error) error {
+	lastError := reason.Error()
+	transfer.LastError = &lastError
+	return s.StatusTransfer(transfer, data.StatusNotSent)
+}
+
+// exhausted checks whether the transfer has no attempts left
+func (s *Service) exhausted(transfer data.Transfer) bool {
+	return transfer.Attempts >= s.cfg.SenderRetry().MaxAttempts
+}
+
+// RetryTransfer returns the transfer to the queue with backoff, or marks it failed if the attempts are exhausted
+func (s *Service) RetryTransfer(transfer data.Transfer, reason error) error {
+	retry := s.cfg.SenderRetry()
+	if s.exhausted(transfer) {
+		return s.FailTransfer(transfer, errors.Wrapf(reason, "attempts are exhausted (%d)", transfer.Attempts))
+	}
+
+	// the transaction is proven not to be included, so it is signed again on the next attempt
+	nextAttemptAt := time.Now().UTC().Add(retry.Delay(transfer.Attempts))
+	transfer.NextAttemptAt = &nextAttemptAt
+	transfer.OdinTxHash = nil
+	transfer.OdinHeight = nil
+	transfer.OdinTimeoutHeight = nil
+	transfer.OdinAmount = nil
+	transfer.OdinDenom = nil
+	transfer.OdinDust = nil
+	return s.ReleaseTransfer(transfer, reason)
+}
+
+// HoldTransfer moves the transfer to the manual review
+func (s *Service) HoldTransfer(transfer data.Transfer, reason error) error {
+	lastError := reason.Error()
+	transfer.LastError = &lastError
+	return s.storage.Transaction(s.ctx, func(tx postgres.Storage) error {
+		if err := tx.Transfers().Transit(s.ctx, transfer, data.StatusOnHold, lastError); err != nil {
+			return errors.Wrap(err, "failed to mark transfer on_hold")
+		}
+		return review.New(s.cfg, tx).Hold(s.ctx, transfer, lastError)
+	})
+}
+
+// UnknownTransfer marks the transfer, which transaction may be included, to be resolved by the chain
+func (s *Service) UnknownTransfer(transfer data.Transfer, reason error) error {
+	lastError := reason.Error()
+	transfer.LastError = &lastError
+	return s.StatusTransfer(transfer, data.StatusUnknown)
+}
+
+// handleFailure marks the transfer depending on the error of the attempt and returns the new status
+func (s *Service) handleFailure(transfer data.Transfer, err error) data.Status {
+	var status data.Status
+	var newErr error
+	switch {
+	case errors.Is(err, odin.ErrOutcomeUnknown):
+		status, newErr = data.StatusUnknown, s.UnknownTransfer(transfer, err)
+	case odin.IsTransient(err) && !s.exhausted(transfer):
+		status, newErr = data.StatusNotSent, s.RetryTransfer(transfer, err)
+	case odin.IsTransient(err):
+		status, newErr = data.StatusFailed, s.RetryTransfer(transfer, err)
+	default:
+		status, newErr = data.StatusFailed, s.FailTransfer(transfer, err)
+	}
+	if newErr != nil {
+		panic(errors.Wrapf(newErr, "failed to mark transfer %d", transfer.ID))
+	}
+	return status
+}
+
+// pause keeps the transfer out of the queue while payouts are paused by the breaker,
+// the transfer above the maximum is held for the manual review, it is exempt from the maximum once approved
+func (s *Service) pause(transfer data.Transfer, err error) data.Status {
+	status, newErr := data.StatusNotSent, error(nil)
+	if errors.Is(err, breaker.ErrMaxTransfer) {
+		status, newErr = data.StatusOnHold, s.HoldTransfer(transfer, err)
+	} else {
+		newErr = s.ReleaseTransfer(transfer, err)
+	}
+	if newErr != nil {
+		panic(errors.Wrapf(newErr, "failed to mark transfer %d", transfer.ID))
+	}
+	return status
+}
+
 func (s *Service) Send() error {
-	transfers, err := s.transfers.New().SelectStatus(data.StatusNotSent)
+	paused, err := pause.New(s.storage).State(s.ctx, data.PausePayouts)
+	if err != nil {
+		return err
+	}
+	if paused.Tripped() {
+		s.log.WithField("paused_at", paused.TrippedAt).Warn("Payouts are paused by the operator")
+		return nil
+	}
+
+	state, err := s.breaker.State(s.ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get breaker state")
+	}
+	if state.Tripped() {
+		s.log.WithField("tripped_at", state.TrippedAt).Warn("Payouts are paused by the outflow breaker")
+		return nil
+	}
+
+	// claimed transfers are already processing, so other instances do not send them
+	transfers, err := s.transfers.New().Claim(s.ctx, s.owner, s.cfg.SenderLease(), s.cfg.SenderBatchSize())
 	if err != nil {
-		return errors.Wrap(err, "failed to select transfers by 'not sent'")
+		return errors.Wrap(err, "failed to claim transfers")
 	}
 
 	if len(transfers) == 0 {
 		return nil
 	}
 
-	s.log.Info("Starting sending")
+	s.log.WithField("claimed", len(transfers)).Info("Starting sending")
+	result := newSummary()
+
+	queue := make(chan data.Transfer)
+	wg := sync.WaitGroup{}
+	for i := 0; i < s.cfg.Job(config.JobSend).Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for transfer := range queue {
+				result.add(s.process(transfer))
+			}
+		}()
+	}
+
+	// every claimed transfer is processed, otherwise it would wait for the lease to expire
 	for _, transfer := range transfers {
-		rateCoef, err := s.odin.GetExchangeRate(transfer.Denom)
+		queue <- transfer
+	}
+	close(queue)
+	wg.Wait()
+
+	result.log(s.log)
+	if result.failed > 0 {
+		return errors.Errorf("failed to send %d of %d transfers", result.failed, len(transfers))
+	}
+	return nil
+}
+
+// process sends the transfer isolating its failures from the other ones, returns the resulting status
+func (s *Service) process(transfer data.Transfer) (status data.Status, err error) {
+	log := s.log.WithField("transfer_id", transfer.ID)
+	started := time.Now()
+
+	defer func() {
+		// the transfer stays processing until its lease expires
+		if rvr := recover(); rvr != nil {
+			status, err = data.StatusProcessing, errors.Errorf("sending panicked: %v", rvr)
+		}
+
+		log = log.WithFields(logrus.Fields{
+			"status":   status,
+			"duration": time.Since(started),
+		})
 		if err != nil {
-			return errors.Wrap(err, "failed to get exchangeDenom rate")
+			log.WithError(err).Error("failed to send transfer")
+			return
 		}
+		log.Info("Transfer sent")
+	}()
 
-		transferAmountRaw, ok := new(big.Int).SetString(transfer.Amount, 10)
-		if !ok {
-			err = errors.Wrap(err, "failed to convert amount to big int")
-			newErr := s.StatusTransfer(transfer, data.StatusFailed)
-			if newErr != nil {
-				panic(errors.Wrap(newErr, "failed to mark status failed"))
-			}
-			return err
+	return s.send(transfer)
+}
+
+// send claims withdrawal of the processing transfer, returns the resulting status and the reason of failure
+func (s *Service) send(transfer data.Transfer) (data.Status, error) {
+	rateCoef, err := s.odin.GetExchangeRate(transfer.Denom)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get exchangeDenom rate")
+		// the failed attempt is counted, so the broken rate feed does not retry the transfer forever
+		transfer.Attempts++
+		status := data.StatusNotSent
+		if s.exhausted(transfer) {
+			status = data.StatusFailed
 		}
+		if newErr := s.RetryTransfer(transfer, err); newErr != nil {
+			panic(errors.Wrap(newErr, "failed to release transfer"))
+		}
+		return status, err
+	}
 
-		binanceToken, ok := s.cfg.BinanceToken(transfer.Denom)
-		if !ok {
-			err = errors.Wrap(err, "failed to find binance token in config")
-			newErr := s.StatusTransfer(transfer, data.StatusFailed)
-			if newErr != nil {
-				panic(errors.Wrap(newErr, "failed to mark status failed"))
-			}
-			return err
+	binanceToken, ok := s.cfg.BinanceToken(transfer.Denom)
+	if !ok {
+		err = errors.Errorf("failed to find binance token in config: %s", transfer.Denom)
+		newErr := s.FailTransfer(transfer, err)
+		if newErr != nil {
+			panic(errors.Wrap(newErr, "failed to mark status failed"))
 		}
-		exchangeDenom, odinPrecision := s.cfg.OdinExchange()
-		transferAmount := sdk.NewDecFromBigIntWithPrec(transferAmountRaw, int64(odinPrecision-binanceToken.Precision)).Mul(rateCoef)
-		s.log.WithFields(logrus.Fields{
-			"withdrawal": transferAmount,
-		}).Info("amount to transfer sending")
+		return data.StatusFailed, err
+	}
 
-		coinAmount, _ := sdk.NewDecCoinFromDec(exchangeDenom, transferAmount).TruncateDecimal()
-		err = s.odin.ClaimWithdrawal(transfer.Address, coinAmount)
-		if err != nil {
-			err = errors.Wrap(err, "failed to claim withdrawal")
-			newErr := s.StatusTransfer(transfer, data.StatusFailed)
-			if newErr != nil {
-				panic(errors.Wrap(newErr, "failed to mark status failed"))
-			}
-			return err
+	// the fee is charged in the binance token as quoted at the claim, only the rest is converted
+	net := new(big.Int).Set(transfer.Amount)
+	if transfer.Fee != nil {
+		net.Sub(net, transfer.Fee)
+	}
+
+	// the rate is applied to the amount shifted by the difference of precisions, the rounded off dust is kept on the transfer
+	exchangeDenom, odinPrecision := s.cfg.OdinExchange()
+	payout, dust := amount.Convert(net, odinPrecision-binanceToken.Precision, amount.FromDec(rateCoef), s.cfg.OdinRounding())
+	s.log.WithFields(logrus.Fields{
+		"transfer_id": transfer.ID,
+		"fee":         transfer.Fee,
+		"withdrawal":  payout.String(),
+		"dust":        amount.FormatDecimal(dust),
+	}).Info("amount to transfer sending")
+
+	if payout.Sign() <= 0 {
+		err = errors.Errorf("amount is converted to %s%s", payout, exchangeDenom)
+		if newErr := s.FailTransfer(transfer, err); newErr != nil {
+			panic(errors.Wrap(newErr, "failed to mark status failed"))
 		}
+		return data.StatusFailed, err
+	}
+	coinAmount := sdk.NewCoin(exchangeDenom, sdk.NewIntFromBigInt(payout))
+	transfer.OdinDust = dust
 
-		err = s.StatusTransfer(transfer, data.StatusSent)
-		if err != nil {
-			panic(errors.Wrap(err, "failed to mark status failed"))
+	withdrawal, status, err := s.sign(&transfer, coinAmount)
+	if err != nil {
+		return status, err
+	}
+
+	// the signer is not locked while broadcasting, so the transfers are sent concurrently
+	txResp, err := s.odin.Broadcast(withdrawal)
+	if txResp != nil {
+		transfer.OdinHeight = &txResp.Height
+	}
+	if err != nil {
+		err = errors.Wrap(err, "failed to claim withdrawal")
+		return s.handleFailure(transfer, err), err
+	}
+
+	s.markSent(transfer, txResp)
+	return data.StatusSent, nil
+}
+
+// sign checks the limits, signs the withdrawal of the transfer and stores its transaction under the signer lock,
+// so the payouts being signed are counted by the breaker, returns the resulting status if the withdrawal is not signed
+func (s *Service) sign(transfer *data.Transfer, coinAmount sdk.Coin) (*odin.Withdrawal, data.Status, error) {
+	s.signer.Lock()
+	defer s.signer.Unlock()
+
+	// the lease may expire while waiting for the signer, then the transfer may be already claimed by another instance
+	if transfer.LeaseExpiresAt != nil && !time.Now().Before(*transfer.LeaseExpiresAt) {
+		return nil, data.StatusProcessing, errors.Errorf("lease of the transfer expired at %s before signing", *transfer.LeaseExpiresAt)
+	}
+
+	if err := s.breaker.Check(s.ctx, *transfer, coinAmount); err != nil {
+		return nil, s.pause(*transfer, err), err
+	}
+
+	transfer.Attempts++
+	withdrawal, err := s.odin.SignWithdrawal(transfer.Address, coinAmount)
+	if err != nil {
+		err = errors.Wrap(err, "failed to sign withdrawal")
+		return nil, s.handleFailure(*transfer, err), err
+	}
+
+	// the transaction is stored before broadcasting, so it can be resolved by hash if the outcome is lost,
+	// it is not broadcast at all if the lease is lost, as another instance may be sending the transfer
+	transfer.OdinTxHash = &withdrawal.TxHash
+	transfer.OdinTimeoutHeight = &withdrawal.TimeoutHeight
+	transfer.OdinAmount = coinAmount.Amount.BigInt()
+	transfer.OdinDenom = &coinAmount.Denom
+	transfer.OdinHeight = nil
+	if err := s.transfers.UpdateTransfer(s.ctx, *transfer); err != nil {
+		s.odin.Discard(withdrawal)
+		return nil, data.StatusProcessing, errors.Wrap(err, "failed to store withdrawal transaction, it is not broadcast")
+	}
+	return withdrawal, data.StatusProcessing, nil
+}
+
+// markSent marks the transfer sent by the included transaction and posts the payout
+func (s *Service) markSent(transfer data.Transfer, txResp *sdk.TxResponse) {
+	sentAt := time.Now().UTC()
+	transfer.SentAt = &sentAt
+	transfer.OdinHeight = &txResp.Height
+	transfer.LastError = nil
+	transfer.NextAttemptAt = nil
+	err := s.storage.Transaction(s.ctx, func(tx postgres.Storage) error {
+		if err := tx.Transfers().Transit(s.ctx, transfer, data.StatusSent, ""); err != nil {
+			return errors.Wrap(err, "failed to transit transfer")
 		}
+		if transfer.OdinDust != nil && transfer.OdinDust.Sign() != 0 {
+			if err := tx.Users().AddOdinDust(s.ctx, transfer.UserID, transfer.OdinDust); err != nil {
+				return err
+			}
+		}
+		return ledger.New(tx).Payout(s.ctx, transfer)
+	})
+	if err != nil {
+		panic(errors.Wrap(err, "failed to mark status sent"))
 	}
-	s.log.Info("Finishing sending")
-	return nil
 }
 
-func (s *Service) Refund() error {
-	transfers, err := s.transfers.New().SelectStatus(data.StatusFailed)
+// Resolve looks for transactions of unknown transfers on chain. The transfer is marked sent once its
+// transaction is included, it is retried or failed only once the chain is past the timeout height.
+// Transactions are looked up by hash only: it is stored before broadcasting, so every unknown transfer has one,
+// while the memo is the same for all transactions and the chain does not index it.
+func (s *Service) Resolve() error {
+	transfers, err := s.transfers.New().SelectStatus(s.ctx, data.StatusUnknown)
 	if err != nil {
-		return errors.Wrap(err, "failed to select transfers by failed")
+		return errors.Wrap(err, "failed to select unknown transfers")
 	}
 
 	if len(transfers) == 0 {
 		return nil
 	}
 
-	s.log.Info("Staring refunding...")
+	// the height is taken before looking for transactions, so the ones not found are surely not included
+	height, err := s.odin.GetLatestHeight()
+	if err != nil {
+		return errors.Wrap(err, "failed to get latest height")
+	}
 
+	failed := 0
 	for _, transfer := range transfers {
-		user, err := s.users.GetUserById(transfer.UserID)
-		if err != nil {
-			return errors.Wrap(err, "failed to get user by id")
+		if err := s.resolve(transfer, height); err != nil {
+			s.log.WithError(err).WithField("transfer_id", transfer.ID).Error("failed to resolve transfer")
+			failed++
 		}
+	}
 
-		userAmount, err := sdk.NewDecFromStr(user.Amount)
-		if err != nil {
-			return errors.Wrap(err, "failed to parse user coins")
+	if failed > 0 {
+		return errors.Errorf("failed to resolve %d of %d transfers", failed, len(transfers))
+	}
+	return nil
+}
+
+func (s *Service) resolve(transfer data.Transfer, height int64) error {
+	if transfer.OdinTxHash == nil || transfer.OdinTimeoutHeight == nil {
+		return errors.New("transfer has no transaction to resolve")
+	}
+
+	txResp, err := s.odin.GetTx(*transfer.OdinTxHash)
+	if err != nil {
+		return errors.Wrap(err, "failed to get transaction")
+	}
+
+	if txResp == nil {
+		// the transaction may be still included
+		if height <= *transfer.OdinTimeoutHeight {
+			return nil
 		}
-		s.log.WithField("amount", userAmount.String()).Info("user amount")
 
-		refundAmount, err := sdk.NewDecFromStr(transfer.Amount)
-		if err != nil {
-			return errors.Wrap(err, "failed to parse refund coins")
+		s.log.WithField("transfer_id", transfer.ID).Info("transaction is not included, retrying transfer")
+		err = errors.Errorf("transaction %s is not included before height %d", *transfer.OdinTxHash, *transfer.OdinTimeoutHeight)
+		if newErr := s.RetryTransfer(transfer, err); newErr != nil {
+			return errors.Wrap(newErr, "failed to retry transfer")
 		}
-		s.log.WithField("amount", refundAmount.String()).Info("amount to refund")
+		return nil
+	}
 
-		user.Amount = userAmount.Add(refundAmount).TruncateInt().String()
+	transfer.OdinHeight = &txResp.Height
+	if err := odin.TxError(txResp); err != nil {
+		status := s.handleFailure(transfer, errors.Wrap(err, "failed to claim withdrawal"))
+		s.log.WithError(err).WithFields(logrus.Fields{
+			"transfer_id": transfer.ID,
+			"status":      status,
+		}).Info("transaction is included, but failed")
+		return nil
+	}
 
-		s.log.WithField("amount", user.Amount).Info("amount after refund")
+	s.log.WithField("transfer_id", transfer.ID).Info("transaction is included, transfer is sent")
+	s.markSent(transfer, txResp)
+	return nil
+}
 
-		if err := s.users.UpdateUser(*user); err != nil {
-			return errors.Wrap(err, "failed to update user")
-		}
+// Recover returns transfers, which leases are expired, to the queue
+func (s *Service) Recover() error {
+	recovered, err := s.transfers.New().RecoverExpired(s.ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to recover expired leases")
+	}
+	if recovered > 0 {
+		s.log.WithField("recovered", recovered).Warn("Transfers with expired lease returned to the queue")
+	}
+	return nil
+}
 
-		transfer.Status = data.StatusRefunded
-		if err := s.transfers.UpdateTransfer(transfer); err != nil {
-			return errors.Wrap(err, "failed to update transfer")
+func (s *Service) Refund() error {
+	transfers, err := s.transfers.New().SelectStatus(s.ctx, data.StatusFailed)
+	if err != nil {
+		return errors.Wrap(err, "failed to select transfers by failed")
+	}
+
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	s.log.Info("Staring refunding...")
+
+	for _, transfer := range transfers {
+		s.log.WithFields(logrus.Fields{
+			"user_id":       transfer.UserID,
+			"refund_amount": transfer.Amount.String(),
+		}).Info("amount to refund")
+
+		err := s.storage.Transaction(s.ctx, func(tx postgres.Storage) error {
+			if err := tx.Transfers().Transit(s.ctx, transfer, data.StatusRefunded, ""); err != nil {
+				return errors.Wrap(err, "failed to transit transfer")
+			}
+			return ledger.New(tx).Refund(s.ctx, transfer)
+		})
+		if errors.Is(err, postgres.ErrStatusConflict) {
+			s.log.WithField("transfer_id", transfer.ID).Debug("transfer is already refunded")
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to refund transfer")
 		}
 	}
 	s.log.Info("Finished refunding...")
@@ -0,0 +1,722 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/amount"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/memory"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/breaker"
+	"github.com/bsc-bridge-svc/internal/services/fees"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/bsc-bridge-svc/internal/services/pause"
+	"github.com/bsc-bridge-svc/internal/services/review"
+	"github.com/bsc-bridge-svc/odin"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	sdkauth "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testDenom         = "odin"
+	testExchangeDenom = "loki"
+	testOdinAddress   = "odin1xyz"
+)
+
+// testConfig overrides only the settings used by sender
+type testConfig struct {
+	config.Config
+	limits map[string]config.Limit
+	fees   map[string]config.FeePolicy
+}
+
+func (testConfig) BinanceToken(denom string) (config.BinanceToken, bool) {
+	if denom != testDenom {
+		return config.BinanceToken{}, false
+	}
+	return config.BinanceToken{Precision: 9}, true
+}
+
+func (testConfig) OdinExchange() (string, int) {
+	return testExchangeDenom, 18
+}
+
+func (testConfig) OdinRounding() amount.Rounding {
+	return amount.RoundDown
+}
+
+func (testConfig) SenderLease() time.Duration {
+	return time.Minute
+}
+
+func (testConfig) SenderBatchSize() uint64 {
+	return 10
+}
+
+func (testConfig) BreakerWindow() time.Duration {
+	return time.Hour
+}
+
+func (c testConfig) BreakerBinanceLimits() map[string]config.Limit {
+	return c.limits
+}
+
+func (testConfig) BreakerOdinLimits() map[string]config.Limit {
+	return nil
+}
+
+func (c testConfig) Fee(denom string) (config.FeePolicy, bool) {
+	policy, ok := c.fees[denom]
+	return policy, ok
+}
+
+func (testConfig) Job(string) config.Job {
+	return config.Job{Enabled: true, Period: time.Millisecond, Concurrency: 4}
+}
+
+func (testConfig) SenderRetry() config.Retry {
+	return config.Retry{MaxAttempts: 2, Backoff: time.Nanosecond, MaxBackoff: time.Nanosecond}
+}
+
+// fakeOdin records claims instead of broadcasting them
+type fakeOdin struct {
+	mu     sync.Mutex
+	claims []sdk.Coin
+	err    error
+	// included makes the transaction included even if broadcast returns error
+	included bool
+	height   int64
+	signed   map[string]sdk.Coin
+	txs      map[string]*sdk.TxResponse
+	// rejected makes signing of withdrawals to the address fail
+	rejected  string
+	discarded int
+	// rateErr makes the exchange rate unavailable
+	rateErr error
+	// broadcasting receives every broadcast, which then waits until released is closed
+	broadcasting chan struct{}
+	released     chan struct{}
+}
+
+func (f *fakeOdin) WithSigner() odin.Client {
+	return f
+}
+
+func (f *fakeOdin) GetAccount(string) (sdkauth.AccountI, error) {
+	return nil, nil
+}
+
+func (f *fakeOdin) SignWithdrawal(address string, amount sdk.Coin) (*odin.Withdrawal, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if address == f.rejected {
+		return nil, errors.New("invalid address")
+	}
+	withdrawal := odin.NewWithdrawal([]byte(fmt.Sprintf("%s:%s:%d", address, amount, len(f.signed))), f.height+10)
+	if f.signed == nil {
+		f.signed = make(map[string]sdk.Coin)
+	}
+	f.signed[withdrawal.TxHash] = amount
+	return withdrawal, nil
+}
+
+func (f *fakeOdin) Discard(*odin.Withdrawal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.discarded++
+}
+
+func (f *fakeOdin) Broadcast(withdrawal *odin.Withdrawal) (*sdk.TxResponse, error) {
+	if f.broadcasting != nil {
+		f.broadcasting <- struct{}{}
+		<-f.released
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil && !f.included {
+		return nil, f.err
+	}
+
+	f.claims = append(f.claims, f.signed[withdrawal.TxHash])
+	resp := &sdk.TxResponse{TxHash: withdrawal.TxHash, Height: f.height + 1}
+	if f.txs == nil {
+		f.txs = make(map[string]*sdk.TxResponse)
+	}
+	f.txs[withdrawal.TxHash] = resp
+	return resp, f.err
+}
+
+func (f *fakeOdin) GetTx(hash string) (*sdk.TxResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.txs[hash], nil
+}
+
+func (f *fakeOdin) GetLatestHeight() (int64, error) {
+	return f.height, nil
+}
+
+func (f *fakeOdin) GetExchangeRate(string) (sdk.Dec, error) {
+	if f.rateErr != nil {
+		return sdk.Dec{}, f.rateErr
+	}
+	return sdk.OneDec(), nil
+}
+
+func newTestService(t *testing.T, client odin.Client) (*Service, postgres.Storage, data.Transfer) {
+	return newTestServiceWithConfig(t, client, testConfig{})
+}
+
+func newTestServiceWithConfig(t *testing.T, client odin.Client, cfg testConfig) (*Service, postgres.Storage, data.Transfer) {
+	ctx := context.Background()
+	storage := memory.NewStorage()
+
+	user := data.User{Address: "0x1", Amount: new(big.Int), Denom: testDenom}
+	var err error
+	if user.ID, err = storage.Users().CreateUser(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %s", err)
+	}
+	if err := ledger.New(storage).Open(ctx, user, big.NewInt(5_000_000_000)); err != nil {
+		t.Fatalf("failed to open balance: %s", err)
+	}
+
+	transfer := data.Transfer{
+		Address: testOdinAddress,
+		Amount:  big.NewInt(2_000_000_000),
+		Denom:   testDenom,
+		Status:  data.StatusNotSent,
+		UserID:  user.ID,
+	}
+	// the fee is quoted at the claim like the handler does, even if the amount does not cover it
+	quote, _ := fees.New(cfg, storage).Quote(transfer.Denom, transfer.Amount)
+	transfer.Fee = quote.Fee
+	if transfer.ID, err = storage.Transfers().CreateTransfer(ctx, transfer); err != nil {
+		t.Fatalf("failed to create transfer: %s", err)
+	}
+	if err := ledger.New(storage).Claim(ctx, transfer); err != nil {
+		t.Fatalf("failed to claim transfer: %s", err)
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	return &Service{
+		cfg:       cfg,
+		ctx:       ctx,
+		log:       log,
+		storage:   storage,
+		transfers: storage.Transfers(),
+		odin:      client,
+		breaker:   breaker.New(cfg, storage),
+		owner:     "test",
+	}, storage, transfer
+}
+
+func TestService_Send(t *testing.T) {
+	client := &fakeOdin{}
+	service, storage, transfer := newTestService(t, client)
+
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+
+	if len(client.claims) != 1 || client.claims[0].Denom != testExchangeDenom {
+		t.Fatalf("unexpected claims: %v", client.claims)
+	}
+
+	sent, _ := storage.Transfers().Get(context.Background(), transfer.ID)
+	if sent.Status != data.StatusSent {
+		t.Fatalf("expected status sent, got %s", sent.Status)
+	}
+	if sent.OdinTxHash == nil || sent.OdinHeight == nil || sent.SentAt == nil || sent.Attempts != 1 {
+		t.Fatalf("transfer details are not stored: %+v", sent)
+	}
+}
+
+func TestService_SendFailedAndRefund(t *testing.T) {
+	client := &fakeOdin{err: errors.New("insufficient treasury")}
+	service, storage, transfer := newTestService(t, client)
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	failed, _ := storage.Transfers().Get(context.Background(), transfer.ID)
+	if failed.Status != data.StatusFailed || failed.LastError == nil {
+		t.Fatalf("expected failed transfer with error, got %+v", failed)
+	}
+
+	if err := service.Refund(); err != nil {
+		t.Fatalf("failed to refund: %s", err)
+	}
+
+	refunded, _ := storage.Transfers().Get(context.Background(), transfer.ID)
+	if refunded.Status != data.StatusRefunded {
+		t.Fatalf("expected status refunded, got %s", refunded.Status)
+	}
+
+	user, _ := storage.Users().GetUserById(context.Background(), transfer.UserID)
+	if user.Amount.Cmp(big.NewInt(5_000_000_000)) != 0 {
+		t.Fatalf("expected balance to be restored, got %s", user.Amount)
+	}
+}
+
+func TestService_SendFee(t *testing.T) {
+	client := &fakeOdin{}
+	cfg := testConfig{fees: map[string]config.FeePolicy{
+		testDenom: {Fixed: big.NewInt(1_000_000), Percent: big.NewRat(1, 1)},
+	}}
+	service, storage, transfer := newTestServiceWithConfig(t, client, cfg)
+	ctx := context.Background()
+
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+
+	// 1% of the amount and the fixed part are charged, only the rest is converted and truncated
+	fee := big.NewInt(21_000_000)
+	if len(client.claims) != 1 || client.claims[0].Amount.Int64() != 1 {
+		t.Fatalf("unexpected claims: %v", client.claims)
+	}
+	sent, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if sent.Fee == nil || sent.Fee.Cmp(fee) != 0 {
+		t.Fatalf("expected fee %s to be stored, got %v", fee, sent.Fee)
+	}
+
+	// the truncated fraction of the payout is tracked on the transfer and the user
+	dust := big.NewRat(979, 1000)
+	user, _ := storage.Users().GetUserById(ctx, transfer.UserID)
+	if sent.OdinDust == nil || sent.OdinDust.Cmp(dust) != 0 || user.OdinDust.Cmp(dust) != 0 {
+		t.Fatalf("expected dust %s, got %v and %v", dust, sent.OdinDust, user.OdinDust)
+	}
+
+	feesService := fees.New(cfg, storage)
+	balances, err := feesService.Balances(ctx)
+	if err != nil || len(balances) != 1 || balances[0].Amount.Cmp(fee) != 0 {
+		t.Fatalf("unexpected fees balances: %+v, %v", balances, err)
+	}
+	treasury, _ := storage.Ledger().SelectBalances(ctx, data.AccountTreasury)
+	if len(treasury) != 1 || treasury[0].Amount.Cmp(big.NewInt(1_979_000_000)) != 0 {
+		t.Fatalf("unexpected treasury balances: %+v", treasury)
+	}
+
+	swept, err := feesService.Sweep(ctx, testDenom, "operator", "withdrawn to the cold wallet")
+	if err != nil || swept.Cmp(fee) != 0 {
+		t.Fatalf("expected fees to be swept, got %v, %v", swept, err)
+	}
+	if swept, _ = feesService.Sweep(ctx, testDenom, "operator", "again"); swept.Sign() != 0 {
+		t.Fatalf("expected nothing to sweep, got %s", swept)
+	}
+}
+
+func TestService_SendFeeNotCovered(t *testing.T) {
+	client := &fakeOdin{}
+	cfg := testConfig{fees: map[string]config.FeePolicy{
+		testDenom: {Min: big.NewInt(2_000_000_000)},
+	}}
+	service, storage, transfer := newTestServiceWithConfig(t, client, cfg)
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	failed, _ := storage.Transfers().Get(context.Background(), transfer.ID)
+	if len(client.claims) != 0 || failed.Status != data.StatusFailed {
+		t.Fatalf("expected transfer not covering the fee to fail, got %+v", failed)
+	}
+}
+
+func TestService_SendQuotedFee(t *testing.T) {
+	client := &fakeOdin{}
+	cfg := testConfig{fees: map[string]config.FeePolicy{testDenom: {Fixed: big.NewInt(1_000_000_000)}}}
+	service, storage, transfer := newTestServiceWithConfig(t, client, cfg)
+
+	// the policy is changed after the claim, the transfer is charged the quoted fee
+	service.cfg = testConfig{}
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+
+	sent, _ := storage.Transfers().Get(context.Background(), transfer.ID)
+	if len(client.claims) != 1 || client.claims[0].Amount.Int64() != 1 {
+		t.Fatalf("unexpected claims: %v", client.claims)
+	}
+	if sent.Fee == nil || sent.Fee.Int64() != 1_000_000_000 {
+		t.Fatalf("expected quoted fee to be kept, got %v", sent.Fee)
+	}
+}
+
+func TestService_SendIsolatesFailures(t *testing.T) {
+	client := &fakeOdin{rejected: "odin1rejected"}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	ids := []int64{transfer.ID}
+	for i := 0; i < 5; i++ {
+		transfer.Address = fmt.Sprintf("odin1valid%d", i)
+		if i == 2 {
+			transfer.Address = client.rejected
+		}
+		id, err := storage.Transfers().CreateTransfer(ctx, transfer)
+		if err != nil {
+			t.Fatalf("failed to create transfer: %s", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	if len(client.claims) != len(ids)-1 {
+		t.Fatalf("expected %d claims, got %v", len(ids)-1, client.claims)
+	}
+	for _, id := range ids {
+		processed, _ := storage.Transfers().Get(ctx, id)
+		expected := data.StatusSent
+		if processed.Address == client.rejected {
+			expected = data.StatusFailed
+		}
+		if processed.Status != expected {
+			t.Fatalf("expected transfer %d to be %s, got %s", id, expected, processed.Status)
+		}
+	}
+}
+
+func TestService_SendBroadcastsConcurrently(t *testing.T) {
+	client := &fakeOdin{broadcasting: make(chan struct{}), released: make(chan struct{})}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := storage.Transfers().CreateTransfer(ctx, transfer); err != nil {
+			t.Fatalf("failed to create transfer: %s", err)
+		}
+	}
+
+	sent := make(chan error)
+	go func() {
+		sent <- service.Send()
+	}()
+
+	// every worker broadcasts its transfer while the other ones are still broadcasting
+	for i := 0; i < 4; i++ {
+		select {
+		case <-client.broadcasting:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected 4 concurrent broadcasts, got %d", i)
+		}
+	}
+	close(client.released)
+
+	if err := <-sent; err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+	if len(client.claims) != 4 {
+		t.Fatalf("expected 4 claims, got %v", client.claims)
+	}
+}
+
+func TestService_SendBreakerCap(t *testing.T) {
+	client := &fakeOdin{}
+	cfg := testConfig{limits: map[string]config.Limit{testDenom: {Cap: big.NewInt(3_000_000_000)}}}
+	service, storage, transfer := newTestServiceWithConfig(t, client, cfg)
+	ctx := context.Background()
+
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+
+	// the second transfer exceeds the cap within the window
+	second, err := storage.Transfers().CreateTransfer(ctx, transfer)
+	if err != nil {
+		t.Fatalf("failed to create transfer: %s", err)
+	}
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	paused, _ := storage.Transfers().Get(ctx, second)
+	if len(client.claims) != 1 || paused.Status != data.StatusNotSent || paused.Attempts != 0 {
+		t.Fatalf("expected transfer to be returned to the queue, got %+v", paused)
+	}
+	state, _ := service.breaker.State(ctx)
+	if !state.Tripped() {
+		t.Fatal("expected breaker to be tripped")
+	}
+
+	// nothing is claimed until the breaker is reset
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+	paused, _ = storage.Transfers().Get(ctx, second)
+	if paused.Status != data.StatusNotSent {
+		t.Fatalf("expected transfer to stay in the queue, got %s", paused.Status)
+	}
+
+	usage, _, err := service.breaker.Usage(ctx)
+	if err != nil || len(usage) != 1 || usage[0].Used.Cmp(transfer.Amount) != 0 {
+		t.Fatalf("unexpected usage: %+v, %v", usage, err)
+	}
+}
+
+func TestService_SendBreakerMaxTransfer(t *testing.T) {
+	client := &fakeOdin{}
+	cfg := testConfig{limits: map[string]config.Limit{testDenom: {MaxTransfer: big.NewInt(1_000_000_000)}}}
+	service, storage, transfer := newTestServiceWithConfig(t, client, cfg)
+	ctx := context.Background()
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	held, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if len(client.claims) != 0 || held.Status != data.StatusOnHold {
+		t.Fatalf("expected transfer to be held, got %+v", held)
+	}
+	reviews, _ := storage.Reviews().SelectReviews(ctx, transfer.ID)
+	if len(reviews) != 1 || reviews[0].Decision != data.ReviewHold {
+		t.Fatalf("expected hold to be recorded, got %+v", reviews)
+	}
+
+	if reset, err := service.breaker.Reset(ctx, "alice"); err != nil || !reset {
+		t.Fatalf("failed to reset breaker: %v, %v", reset, err)
+	}
+	state, _ := service.breaker.State(ctx)
+	if state.Tripped() || state.ResetBy == nil || *state.ResetBy != "alice" {
+		t.Fatalf("unexpected breaker state: %+v", state)
+	}
+
+	// the approved transfer is sent without tripping the breaker again
+	if _, err := review.New(cfg, storage).Approve(ctx, transfer.ID, "alice", "verified with the holder"); err != nil {
+		t.Fatalf("failed to approve transfer: %s", err)
+	}
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+	sent, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if len(client.claims) != 1 || sent.Status != data.StatusSent {
+		t.Fatalf("expected approved transfer to be sent, got %+v", sent)
+	}
+	if state, _ := service.breaker.State(ctx); state.Tripped() {
+		t.Fatal("approved transfer must not trip the breaker")
+	}
+}
+
+func TestService_SendPaused(t *testing.T) {
+	client := &fakeOdin{}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	if _, err := pause.New(storage).Pause(ctx, data.PausePayouts, "alice", "incident"); err != nil {
+		t.Fatalf("failed to pause payouts: %s", err)
+	}
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+	paused, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if len(client.claims) != 0 || paused.Status != data.StatusNotSent {
+		t.Fatalf("expected transfer not to be sent while paused, got %+v", paused)
+	}
+
+	if _, err := pause.New(storage).Resume(ctx, data.PausePayouts, "alice"); err != nil {
+		t.Fatalf("failed to resume payouts: %s", err)
+	}
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+	if len(client.claims) != 1 {
+		t.Fatalf("expected transfer to be sent after resume, got %v", client.claims)
+	}
+}
+
+func TestService_SendLeased(t *testing.T) {
+	client := &fakeOdin{}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	// another instance has claimed the transfer and died, its lease is already expired
+	claimed, err := storage.Transfers().Claim(ctx, "other", -time.Second, 10)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("failed to claim transfer: %v, %v", claimed, err)
+	}
+
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+	if len(client.claims) != 0 {
+		t.Fatalf("transfer leased by another instance must not be sent: %v", client.claims)
+	}
+
+	if err := service.Recover(); err != nil {
+		t.Fatalf("failed to recover: %s", err)
+	}
+	if err := service.Send(); err != nil {
+		t.Fatalf("failed to send: %s", err)
+	}
+	if len(client.claims) != 1 {
+		t.Fatalf("expected recovered transfer to be sent once, got %v", client.claims)
+	}
+
+	sent, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if sent.Status != data.StatusSent || sent.LeaseOwner != nil {
+		t.Fatalf("unexpected transfer: %+v", sent)
+	}
+}
+
+func TestService_SendLeaseLost(t *testing.T) {
+	client := &fakeOdin{}
+	service, storage, _ := newTestService(t, client)
+	ctx := context.Background()
+
+	// the lease expired while the transfer was waiting for the signer
+	expired, err := storage.Transfers().Claim(ctx, service.owner, -time.Second, 10)
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("failed to claim transfer: %v, %v", expired, err)
+	}
+	if status, err := service.send(expired[0]); err == nil || status != data.StatusProcessing || len(client.signed) != 0 {
+		t.Fatalf("expected transfer with expired lease not to be signed, got %s, %v", status, err)
+	}
+
+	// the clock of the instance is behind, but the transfer is already claimed by another one
+	if _, err := storage.Transfers().RecoverExpired(ctx); err != nil {
+		t.Fatalf("failed to recover: %s", err)
+	}
+	if _, err := storage.Transfers().Claim(ctx, "other", time.Minute, 10); err != nil {
+		t.Fatalf("failed to claim transfer: %s", err)
+	}
+	leaseExpiresAt := time.Now().Add(time.Minute)
+	expired[0].LeaseExpiresAt = &leaseExpiresAt
+	if status, err := service.send(expired[0]); !errors.Is(err, postgres.ErrStatusConflict) || status != data.StatusProcessing {
+		t.Fatalf("expected stale lease to conflict, got %s, %v", status, err)
+	}
+	if len(client.claims) != 0 || client.discarded != 1 {
+		t.Fatalf("transfer leased by another instance must not be broadcast: %v", client.claims)
+	}
+}
+
+func TestService_SendRetry(t *testing.T) {
+	client := &fakeOdin{err: sdkerrors.Wrap(sdkerrors.ErrWrongSequence, "account sequence mismatch")}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	retried, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if retried.Status != data.StatusNotSent || retried.Attempts != 1 || retried.NextAttemptAt == nil {
+		t.Fatalf("expected transient failure to be retried, got %+v", retried)
+	}
+
+	// the budget of attempts is exhausted on the second failure
+	time.Sleep(time.Millisecond)
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	failed, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if failed.Status != data.StatusFailed || failed.Attempts != 2 {
+		t.Fatalf("expected transfer to fail after 2 attempts, got %+v", failed)
+	}
+}
+
+func TestService_SendRateUnavailable(t *testing.T) {
+	client := &fakeOdin{rateErr: errors.New("rate feed is down")}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	retried, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if retried.Status != data.StatusNotSent || retried.Attempts != 1 || retried.NextAttemptAt == nil {
+		t.Fatalf("expected failed attempt to be counted, got %+v", retried)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	failed, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if failed.Status != data.StatusFailed || failed.Attempts != 2 {
+		t.Fatalf("expected transfer to fail after 2 attempts, got %+v", failed)
+	}
+}
+
+func TestService_ResolveIncluded(t *testing.T) {
+	client := &fakeOdin{err: errors.Wrap(odin.ErrOutcomeUnknown, "timed out"), included: true}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	unknown, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if unknown.Status != data.StatusUnknown || unknown.OdinTxHash == nil {
+		t.Fatalf("expected unknown transfer with transaction, got %+v", unknown)
+	}
+
+	// unknown transfers must not be refunded
+	if err := service.Refund(); err != nil {
+		t.Fatalf("failed to refund: %s", err)
+	}
+
+	if err := service.Resolve(); err != nil {
+		t.Fatalf("failed to resolve: %s", err)
+	}
+
+	sent, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if sent.Status != data.StatusSent || len(client.claims) != 1 {
+		t.Fatalf("expected included transfer to be sent, got %+v", sent)
+	}
+
+	user, _ := storage.Users().GetUserById(ctx, transfer.UserID)
+	if user.Amount.Cmp(big.NewInt(3_000_000_000)) != 0 {
+		t.Fatalf("expected balance not to be refunded, got %s", user.Amount)
+	}
+}
+
+func TestService_ResolveNotIncluded(t *testing.T) {
+	client := &fakeOdin{err: errors.Wrap(odin.ErrOutcomeUnknown, "timed out")}
+	service, storage, transfer := newTestService(t, client)
+	ctx := context.Background()
+
+	if err := service.Send(); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	// the transaction still may be included before the timeout height
+	client.height += 10
+	if err := service.Resolve(); err != nil {
+		t.Fatalf("failed to resolve: %s", err)
+	}
+	unknown, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if unknown.Status != data.StatusUnknown {
+		t.Fatalf("expected transfer to stay unknown, got %s", unknown.Status)
+	}
+
+	client.height++
+	if err := service.Resolve(); err != nil {
+		t.Fatalf("failed to resolve: %s", err)
+	}
+	retried, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if retried.Status != data.StatusNotSent || retried.OdinTxHash != nil {
+		t.Fatalf("expected transfer to be retried, got %+v", retried)
+	}
+}
@@ -0,0 +1,47 @@
+package sender
+
+import (
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// summary collects results of transfers processed concurrently during a single run
+type summary struct {
+	mu       sync.Mutex
+	started  time.Time
+	statuses map[data.Status]int
+	failed   int
+}
+
+func newSummary() *summary {
+	return &summary{
+		started:  time.Now(),
+		statuses: make(map[data.Status]int),
+	}
+}
+
+func (s *summary) add(status data.Status, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statuses[status]++
+	if err != nil {
+		s.failed++
+	}
+}
+
+func (s *summary) log(log *logrus.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := logrus.Fields{
+		"failed":   s.failed,
+		"duration": time.Since(s.started),
+	}
+	for status, count := range s.statuses {
+		fields[string(status)] = count
+	}
+	log.WithFields(fields).Info("Finishing sending")
+}
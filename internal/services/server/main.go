@@ -2,14 +2,21 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data/memory"
 	"github.com/bsc-bridge-svc/internal/data/postgres"
 	"github.com/bsc-bridge-svc/internal/services"
 	"github.com/bsc-bridge-svc/internal/services/bridge"
+	"github.com/bsc-bridge-svc/internal/services/leader"
 	"github.com/bsc-bridge-svc/internal/services/sender"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/bsc-bridge-svc/internal/web/admin"
 	"github.com/bsc-bridge-svc/internal/web/ctx"
 	"github.com/bsc-bridge-svc/internal/web/handlers"
+	"github.com/bsc-bridge-svc/internal/web/idempotency"
 	"github.com/bsc-bridge-svc/internal/web/logging"
+	"github.com/bsc-bridge-svc/internal/web/requests"
 	"github.com/go-chi/chi"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -18,24 +25,54 @@ import (
 )
 
 type Service struct {
-	cfg    config.Config
-	ctx    context.Context
-	log    *logrus.Logger
-	bridge *bridge.Service
-	sender *sender.Service
+	cfg     config.Config
+	ctx     context.Context
+	log     *logrus.Logger
+	storage postgres.Storage
+	bridge  *bridge.Service
+	sender  *sender.Service
+	jobs    *services.Supervisor
+	leader  *leader.Service
 }
 
+// Mode defines which parts of the service are run
+type Mode string
+
+const (
+	// ModeAPI serves requests only
+	ModeAPI Mode = "api"
+	// ModeWorker runs background jobs only, health of the jobs is served
+	ModeWorker Mode = "worker"
+	// ModeAll serves requests and runs background jobs
+	ModeAll Mode = "all"
+)
+
+// jobRestart is the backoff of restarting the crashed job
+var jobRestart = config.Retry{Backoff: time.Second, MaxBackoff: time.Minute}
+
 func New(cfg config.Config, ctx context.Context) *Service {
 	return &Service{
-		cfg:    cfg,
-		ctx:    ctx,
-		log:    cfg.Logger(),
-		bridge: bridge.New(cfg),
-		sender: sender.New(cfg, ctx),
+		cfg:     cfg,
+		ctx:     ctx,
+		log:     cfg.Logger(),
+		storage: newStorage(cfg),
+		bridge:  bridge.New(cfg),
+		jobs:    services.NewSupervisor(cfg.Logger(), jobRestart),
+	}
+}
+
+// newStorage uses the in-memory storage if it is enabled in config, postgres otherwise
+func newStorage(cfg config.Config) postgres.Storage {
+	if cfg.DBInMemory() {
+		cfg.Logger().Warn("Using in-memory storage, data will be lost on restart")
+		return memory.NewStorage()
 	}
+	return postgres.NewStorage(cfg)
 }
 
-func (s *Service) Run() error {
+// Run serves requests and runs background jobs of the mode until ctx is done,
+// then waits for them within the shutdown timeout
+func (s *Service) Run(mode Mode) error {
 	defer func() {
 		// recover if something has broken
 		if rvr := recover(); rvr != nil {
@@ -43,14 +80,103 @@ func (s *Service) Run() error {
 		}
 	}()
 
-	go services.RunWithPeriod(s.log, 5*time.Second, s.sender.Send)
-	go services.RunWithPeriod(s.log, 10*time.Second, s.sender.Refund)
+	handler := s.router()
+	switch mode {
+	case ModeAPI:
+	case ModeWorker:
+		s.startJobs()
+		handler = s.healthRouter()
+	case ModeAll:
+		s.startJobs()
+	default:
+		return errors.Errorf("unknown run mode: %s", mode)
+	}
+
+	server := &http.Server{Addr: s.cfg.Address(), Handler: handler}
+	listened := make(chan error, 1)
+	go func() {
+		listened <- server.ListenAndServe()
+	}()
+	s.log.WithFields(logrus.Fields{
+		"port": s.cfg.Address(),
+		"mode": mode,
+	}).Info("Starting server")
 
-	s.log.WithField("port", s.cfg.Address()).Info("Starting server")
-	if err := http.ListenAndServe(s.cfg.Address(), s.router()); err != nil {
+	select {
+	case err := <-listened:
 		return errors.Wrap(err, "listener failed")
+	case <-s.ctx.Done():
 	}
-	return nil
+
+	s.log.WithField("timeout", s.cfg.ShutdownTimeout()).Info("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout())
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		return errors.Wrap(err, "failed to drain requests")
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		if s.leader != nil {
+			if err := s.leader.Resign(ctx); err != nil {
+				return errors.Wrap(err, "failed to resign from the leadership")
+			}
+		}
+		s.log.Info("Service stopped")
+		return nil
+	case <-ctx.Done():
+		// unfinished transfers stay claimed and are recovered after their lease expires
+		return errors.New("jobs have not finished before the shutdown timeout")
+	}
+}
+
+// startJobs starts the enabled background jobs
+func (s *Service) startJobs() {
+	// jobs are stopped through ctx, but the work in progress is not cancelled to be finished consistently
+	s.sender = sender.New(s.cfg, context.Background(), s.storage)
+	s.leader = leader.New(s.cfg, "bsc-bridge-svc")
+
+	if err := s.campaign(); err != nil {
+		s.log.WithError(err).Error("failed to campaign for the leadership")
+	}
+	s.startJob(config.JobLeader, s.campaign)
+
+	// claimed transfers are owned by a single replica, so all of them may send
+	s.startJob(config.JobSend, s.sender.Send)
+	// singleton jobs are run by the leader only
+	s.startJob(config.JobRefund, s.leader.Only(s.sender.Refund))
+	s.startJob(config.JobRecover, s.leader.Only(s.sender.Recover))
+	s.startJob(config.JobResolve, s.leader.Only(s.sender.Resolve))
+	s.startJob(config.JobIdempotencyCleanup, s.leader.Only(s.deleteExpiredIdempotencyKeys))
+}
+
+func (s *Service) startJob(name string, run func() error) {
+	job := s.cfg.Job(name)
+	if !job.Enabled {
+		s.log.WithField("job", name).Info("Job is disabled")
+		return
+	}
+	s.jobs.Go(s.ctx, name, job.Period, run)
+}
+
+// healthRouter serves the health of the jobs only
+func (s *Service) healthRouter() chi.Router {
+	router := chi.NewRouter()
+	router.Use(
+		logging.Middleware(s.log),
+		ctx.Middleware(
+			ctx.CtxLog(s.log),
+			ctx.CtxJobs(s.jobs),
+		),
+	)
+	router.Get("/health", handlers.GetHealth)
+	return router
 }
 
 func (s *Service) router() chi.Router {
@@ -61,16 +187,66 @@ func (s *Service) router() chi.Router {
 		ctx.Middleware(
 			ctx.CtxLog(s.log),
 			ctx.CtxConfig(s.cfg),
-			ctx.CtxUsers(postgres.NewUsers(s.cfg)),
-			ctx.CtxTransfers(postgres.NewTransfers(s.cfg)),
+			ctx.CtxUsers(s.storage.Users()),
+			ctx.CtxTransfers(s.storage.Transfers()),
+			ctx.CtxStorage(s.storage),
 			ctx.CtxBridge(s.bridge),
+			ctx.CtxJobs(s.jobs),
 		),
 	)
 
 	// routes of the service
+	router.Get("/health", handlers.GetHealth)
 	router.Route("/bsc/exchange", func(r chi.Router) {
-		r.Post("/", handlers.GetUser)
+		r.With(idempotency.Middleware(requests.IdempotencyScope)).Post("/", handlers.GetUser)
+	})
+	router.Get("/bsc/quote", handlers.GetQuote)
+	router.Route("/bsc/transfers", func(r chi.Router) {
+		r.Get("/", handlers.GetTransfers)
+		r.Get(fmt.Sprintf("/{%s}", web.IDRequestKey), handlers.GetTransfer)
+		r.Post(fmt.Sprintf("/{%s}/cancel", web.IDRequestKey), handlers.CancelTransfer)
+	})
+	router.Route("/admin/transfers", func(r chi.Router) {
+		r.Use(admin.Middleware(admin.RoleReview))
+		r.Get("/", handlers.GetAdminTransfers)
+		r.Get("/held", handlers.GetHeldTransfers)
+		r.Get(fmt.Sprintf("/{%s}", web.IDRequestKey), handlers.GetAdminTransfer)
+		r.Post(fmt.Sprintf("/{%s}/approve", web.IDRequestKey), handlers.ApproveTransfer)
+		r.Post(fmt.Sprintf("/{%s}/reject", web.IDRequestKey), handlers.RejectTransfer)
+	})
+	router.Route("/admin/pauses", func(r chi.Router) {
+		r.Use(admin.Middleware(admin.RolePauses))
+		r.Get("/", handlers.GetPauses)
+		r.Post(fmt.Sprintf("/{%s}/pause", web.TargetRequestKey), handlers.Pause)
+		r.Post(fmt.Sprintf("/{%s}/resume", web.TargetRequestKey), handlers.Resume)
+	})
+	router.Route("/admin/breaker", func(r chi.Router) {
+		r.Use(admin.Middleware(admin.RoleBreaker))
+		r.Get("/", handlers.GetBreaker)
+		r.Post("/reset", handlers.ResetBreaker)
+	})
+	router.Route("/admin/fees", func(r chi.Router) {
+		r.Use(admin.Middleware(admin.RoleFees))
+		r.Get("/", handlers.GetFees)
+		r.Post("/sweep", handlers.SweepFees)
 	})
 
 	return router
 }
+
+// campaign keeps or acquires the leadership of the replica
+func (s *Service) campaign() error {
+	return s.leader.Campaign(s.ctx)
+}
+
+// deleteExpiredIdempotencyKeys removes stored responses which are out of the retention period
+func (s *Service) deleteExpiredIdempotencyKeys() error {
+	deleted, err := s.storage.IdempotencyKeys().DeleteExpired(s.ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete expired idempotency keys")
+	}
+	if deleted > 0 {
+		s.log.WithField("deleted", deleted).Info("Expired idempotency keys deleted")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JobState is the state of the supervised job
+type JobState struct {
+	Name          string     `json:"name"`
+	Running       bool       `json:"running"`
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	LastError     *string    `json:"last_error"`
+	LastErrorAt   *time.Time `json:"last_error_at"`
+	Restarts      int        `json:"restarts"`
+}
+
+func (j JobState) ToReturn() map[string]interface{} {
+	return map[string]interface{}{
+		"name":            j.Name,
+		"running":         j.Running,
+		"last_success_at": j.LastSuccessAt,
+		"last_error":      j.LastError,
+		"last_error_at":   j.LastErrorAt,
+		"restarts":        j.Restarts,
+	}
+}
+
+// Supervisor runs periodic jobs, restarting them with backoff after panics
+type Supervisor struct {
+	log     *logrus.Logger
+	restart config.Retry
+
+	mu   sync.RWMutex
+	jobs map[string]*JobState
+	wg   sync.WaitGroup
+}
+
+// NewSupervisor creates the supervisor, restart defines the backoff between consecutive crashes of the job
+func NewSupervisor(log *logrus.Logger, restart config.Retry) *Supervisor {
+	return &Supervisor{
+		log:     log,
+		restart: restart,
+		jobs:    make(map[string]*JobState),
+	}
+}
+
+// Go starts the job which runs every period until ctx is done
+func (s *Supervisor) Go(ctx context.Context, name string, period time.Duration, run func() error) {
+	s.mu.Lock()
+	s.jobs[name] = &JobState{Name: name, Running: true}
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.supervise(ctx, name, period, run)
+	}()
+}
+
+// Wait blocks until all the jobs are stopped
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// States returns the states of all the jobs ordered by name
+func (s *Supervisor) States() []JobState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]JobState, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		result = append(result, *job)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Healthy reports whether none of the jobs is waiting for the restart
+func (s *Supervisor) Healthy() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, job := range s.jobs {
+		if !job.Running {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Supervisor) supervise(ctx context.Context, name string, period time.Duration, run func() error) {
+	log := s.log.WithField("job", name)
+	// crashes is the number of consecutive crashes, it is reset once the job succeeds
+	crashes := 0
+	for {
+		succeeded := false
+		err := s.runUntilCrash(ctx, period, func() error {
+			err := run()
+			s.record(name, err)
+			succeeded = succeeded || err == nil
+			return err
+		})
+		if err == nil {
+			return
+		}
+
+		if succeeded {
+			crashes = 0
+		}
+		crashes++
+		delay := s.restart.Delay(crashes)
+		log.WithError(err).WithField("delay", delay).Error("Job crashed, restarting")
+
+		s.mu.Lock()
+		s.jobs[name].Running = false
+		s.mu.Unlock()
+		s.record(name, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		s.mu.Lock()
+		s.jobs[name].Running = true
+		s.jobs[name].Restarts++
+		s.mu.Unlock()
+	}
+}
+
+// runUntilCrash runs the job until ctx is done, returns the error if the job has panicked
+func (s *Supervisor) runUntilCrash(ctx context.Context, period time.Duration, run func() error) (err error) {
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			err = errors.Errorf("job panicked: %v", rvr)
+		}
+	}()
+
+	RunWithPeriod(ctx, s.log, period, run)
+	return nil
+}
+
+// record stores the result of the job run
+func (s *Supervisor) record(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	job := s.jobs[name]
+	if err != nil {
+		message := err.Error()
+		job.LastError = &message
+		job.LastErrorAt = &now
+		return
+	}
+	job.LastSuccessAt = &now
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSupervisor_Restart(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	supervisor := NewSupervisor(log, config.Retry{Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	var runs int32
+	supervisor.Go(ctx, "flaky", time.Millisecond, func() error {
+		switch atomic.AddInt32(&runs, 1) {
+		case 1:
+			panic("status update failed")
+		case 2:
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&runs) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	supervisor.Wait()
+
+	states := supervisor.States()
+	if len(states) != 1 {
+		t.Fatalf("expected single job, got %+v", states)
+	}
+	state := states[0]
+	if state.Restarts != 1 || !state.Running || !supervisor.Healthy() {
+		t.Fatalf("expected job to be restarted once, got %+v", state)
+	}
+	if state.LastSuccessAt == nil || state.LastError == nil || *state.LastError != "temporary failure" {
+		t.Fatalf("expected job results to be tracked, got %+v", state)
+	}
+}
@@ -1,22 +1,21 @@
 package services
 
 import (
+	"context"
 	"github.com/sirupsen/logrus"
 	"time"
 )
 
-func RunWithPeriod(log *logrus.Logger, period time.Duration, run func() error) {
-	defer func() {
-		// recover if something has broken
-		if rvr := recover(); rvr != nil {
-			log.Error("app panicked\n", rvr)
-		}
-	}()
-
+// RunWithPeriod calls run every period until ctx is done, the run in progress is not interrupted.
+// Panics of run are not recovered, use Supervisor to restart the crashed job.
+func RunWithPeriod(ctx context.Context, log *logrus.Logger, period time.Duration, run func() error) {
 	uptimeTicker := time.NewTicker(period)
+	defer uptimeTicker.Stop()
 
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case <-uptimeTicker.C:
 			err := run()
 			if err != nil {
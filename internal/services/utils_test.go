@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunWithPeriod_Stop(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs int32
+	stopped := make(chan struct{})
+	go func() {
+		RunWithPeriod(ctx, log, time.Millisecond, func() error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+		close(stopped)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected job to stop after cancellation")
+	}
+	if atomic.LoadInt32(&runs) == 0 {
+		t.Fatal("expected job to run before cancellation")
+	}
+}
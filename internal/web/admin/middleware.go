@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// Roles of operators, each allows a group of admin routes
+const (
+	RoleReview  = "review"
+	RolePauses  = "pauses"
+	RoleBreaker = "breaker"
+	RoleFees    = "fees"
+)
+
+// Middleware authenticates the operator by the admin token in the Authorization header
+// and allows the request only if the operator has the role
+func Middleware(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, bearerPrefix) {
+				render.Respond(w, http.StatusUnauthorized, render.Message("admin token is required"))
+				return
+			}
+
+			operator, ok := ctx.Config(r).AdminOperator(strings.TrimPrefix(header, bearerPrefix))
+			if !ok {
+				ctx.Log(r).Warn("request with unknown admin token")
+				render.Respond(w, http.StatusUnauthorized, render.Message("admin token is invalid"))
+				return
+			}
+			if !operator.HasRole(role) {
+				ctx.Log(r).WithField("operator", operator.Name).WithField("role", role).Warn("request without the admin role")
+				render.Respond(w, http.StatusForbidden, render.Message("operator does not have the role: "+role))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx.CtxOperator(operator.Name)(r.Context())))
+		})
+	}
+}
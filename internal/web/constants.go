@@ -2,4 +2,6 @@ package web
 
 const (
 	AddressRequestKey = "address"
+	IDRequestKey      = "id"
+	TargetRequestKey  = "target"
 )
\ No newline at end of file
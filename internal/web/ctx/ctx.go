@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/bsc-bridge-svc/internal/config"
 	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services"
 	"github.com/bsc-bridge-svc/internal/services/bridge"
 	"github.com/sirupsen/logrus"
 	"net/http"
@@ -15,6 +16,9 @@ const (
 	ctxUsers     = "ctxUsers"
 	ctxBridge    = "ctxBridge"
 	ctxTransfers = "ctxTransfer"
+	ctxStorage   = "ctxStorage"
+	ctxOperator  = "ctxOperator"
+	ctxJobs      = "ctxJobs"
 )
 
 // context getters and setters
@@ -67,5 +71,37 @@ func CtxTransfers(transfers postgres.Transfers) func(context.Context) context.Co
 }
 
 func Transfers(r *http.Request) postgres.Transfers {
-	return r.Context().Value(ctxTransfers).(postgres.Transfers)
+	return r.Context().Value(ctxTransfers).(postgres.Transfers).New()
+}
+
+func CtxStorage(storage postgres.Storage) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, ctxStorage, storage)
+	}
+}
+
+func Storage(r *http.Request) postgres.Storage {
+	return r.Context().Value(ctxStorage).(postgres.Storage)
+}
+
+func CtxOperator(operator string) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, ctxOperator, operator)
+	}
+}
+
+// Operator returns the name of the authenticated operator, empty if the request is not authenticated
+func Operator(r *http.Request) string {
+	operator, _ := r.Context().Value(ctxOperator).(string)
+	return operator
+}
+
+func CtxJobs(jobs *services.Supervisor) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, ctxJobs, jobs)
+	}
+}
+
+func Jobs(r *http.Request) *services.Supervisor {
+	return r.Context().Value(ctxJobs).(*services.Supervisor)
 }
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"github.com/bsc-bridge-svc/internal/services/breaker"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"net/http"
+)
+
+// GetBreaker returns the state of the outflow breaker and the current usage against the caps
+func GetBreaker(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+	service := breaker.New(ctx.Config(r), ctx.Storage(r))
+
+	state, err := service.State(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to get breaker state")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	binance, odin, err := service.Usage(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to get outflow usage")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	result := state.ToReturn()
+	result["window"] = ctx.Config(r).BreakerWindow().String()
+	result["binance"] = usagesToReturn(binance)
+	result["odin"] = usagesToReturn(odin)
+	render.Respond(w, http.StatusOK, render.Message(result))
+}
+
+// ResetBreaker resumes payouts paused by the outflow breaker
+func ResetBreaker(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+	service := breaker.New(ctx.Config(r), ctx.Storage(r))
+
+	reset, err := service.Reset(r.Context(), ctx.Operator(r))
+	if err != nil {
+		log.WithError(err).Error("failed to reset breaker")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	if !reset {
+		render.Respond(w, http.StatusConflict, render.Message("outflow breaker is not tripped"))
+		return
+	}
+
+	log.WithField("operator", ctx.Operator(r)).Warn("Outflow breaker is reset")
+	state, err := service.State(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to get breaker state")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	render.Respond(w, http.StatusOK, render.Message(state.ToReturn()))
+}
+
+func usagesToReturn(usages []breaker.Usage) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, usage.ToReturn())
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/web/admin"
+	"github.com/go-chi/chi"
+	"net/http"
+	"testing"
+)
+
+func breakerRouter() http.HandlerFunc {
+	router := chi.NewRouter()
+	router.Use(admin.Middleware(admin.RoleBreaker))
+	router.Get("/", GetBreaker)
+	router.Post("/reset", ResetBreaker)
+	return router.ServeHTTP
+}
+
+func TestBreaker(t *testing.T) {
+	storage, _ := newTestStorage(t, 0)
+
+	reset := adminRequest(http.MethodPost, "/reset", "", testAdminToken)
+	if w := serve(storage, breakerRouter(), reset); w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if _, err := storage.Breakers().Trip(context.Background(), data.BreakerOutflow, "cap exceeded"); err != nil {
+		t.Fatalf("failed to trip breaker: %s", err)
+	}
+
+	w := serve(storage, breakerRouter(), adminRequest(http.MethodGet, "/", "", testAdminToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Message struct {
+			Tripped bool `json:"tripped"`
+			Binance []struct {
+				Denom string `json:"denom"`
+				Used  string `json:"used"`
+				Cap   string `json:"cap"`
+			} `json:"binance"`
+		} `json:"message"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	usage := response.Message.Binance
+	if !response.Message.Tripped || len(usage) != 1 || usage[0].Used != "0" || usage[0].Cap != "100000000000" {
+		t.Fatalf("unexpected breaker state: %+v", response.Message)
+	}
+
+	// the review operators are not allowed to reset the breaker
+	reset = adminRequest(http.MethodPost, "/reset", "", testReviewerToken)
+	if w := serve(storage, breakerRouter(), reset); w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if state, _ := storage.Breakers().Get(context.Background(), data.BreakerOutflow); !state.Tripped() {
+		t.Fatalf("expected breaker to stay tripped, got %+v", state)
+	}
+
+	reset = adminRequest(http.MethodPost, "/reset", "", testAdminToken)
+	if w := serve(storage, breakerRouter(), reset); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	state, _ := storage.Breakers().Get(context.Background(), data.BreakerOutflow)
+	if state.Tripped() || state.ResetBy == nil || *state.ResetBy != testOperator {
+		t.Fatalf("unexpected breaker state: %+v", state)
+	}
+}
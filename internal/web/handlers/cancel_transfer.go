@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	"github.com/bsc-bridge-svc/internal/web/signature"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+func CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewCancelTransferRequest(r)
+	if err != nil {
+		if verr, ok := err.(validation.Errors); ok {
+			log.WithError(verr).Debug("failed to parse cancel transfer request")
+			render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", verr.Error())))
+			return
+		}
+		log.WithError(err).Debug("failed to decode cancel transfer request")
+		render.Respond(w, http.StatusBadRequest, render.Message("failed to decode request body"))
+		return
+	}
+
+	transfer, err := ctx.Transfers(r).Get(r.Context(), request.ID)
+	if err != nil {
+		log.WithError(err).Error("failed to get transfer")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	if transfer == nil {
+		render.Respond(w, http.StatusNotFound, render.Message(fmt.Sprintf("transfer not found: %d", request.ID)))
+		return
+	}
+
+	user, err := ctx.Users(r).GetUserById(r.Context(), transfer.UserID)
+	if err != nil || user == nil {
+		log.WithError(err).Error("failed to get owner of transfer")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	message := requests.CancelTransferMessage(transfer.ID)
+	if err := signature.Verify(ethcommon.HexToAddress(user.Address), message, request.Signature); err != nil {
+		log.WithError(err).Debug("failed to verify cancel signature")
+		render.Respond(w, http.StatusUnauthorized, render.Message("signature is invalid"))
+		return
+	}
+
+	if !data.CanTransit(transfer.Status, data.StatusCancelled) {
+		render.Respond(w, http.StatusConflict, render.Message(fmt.Sprintf("transfer can not be cancelled: %s", transfer.Status)))
+		return
+	}
+
+	// the status is checked again by the transition, so the transfer claimed by sender meanwhile is not cancelled
+	err = ctx.Storage(r).Transaction(r.Context(), func(tx postgres.Storage) error {
+		if err := tx.Transfers().Transit(r.Context(), *transfer, data.StatusCancelled, "cancelled by user"); err != nil {
+			return err
+		}
+		return ledger.New(tx).Cancel(r.Context(), *transfer)
+	})
+	if errors.Is(err, postgres.ErrStatusConflict) {
+		render.Respond(w, http.StatusConflict, render.Message("transfer is already processed"))
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to cancel transfer")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	log.WithField("transfer_id", transfer.ID).Info("Transfer cancelled")
+	transfer.Status = data.StatusCancelled
+	render.Respond(w, http.StatusOK, render.Message(transfer.ToReturn()))
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-chi/chi"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sign signs the message like wallets do with personal_sign
+func sign(t *testing.T, key *ecdsa.PrivateKey, message string) string {
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatalf("failed to sign message: %s", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(sig)
+}
+
+func cancelRouter() http.HandlerFunc {
+	router := chi.NewRouter()
+	router.Post(fmt.Sprintf("/{%s}/cancel", web.IDRequestKey), CancelTransfer)
+	return router.ServeHTTP
+}
+
+func cancelRequest(id int64, signature string) *http.Request {
+	body := `{"signature":"` + signature + `"}`
+	return httptest.NewRequest(http.MethodPost, fmt.Sprintf("/%d/cancel", id), strings.NewReader(body))
+}
+
+func TestCancelTransfer(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	storage, _ := newTestStorage(t, 0)
+	user := data.User{Address: crypto.PubkeyToAddress(key.PublicKey).Hex(), Amount: new(big.Int), Denom: testDenom}
+	user.ID, _ = storage.Users().CreateUser(ctx, user)
+	if err := ledger.New(storage).Open(ctx, user, big.NewInt(10)); err != nil {
+		t.Fatalf("failed to open balance: %s", err)
+	}
+
+	transfer := data.Transfer{Address: testOdinAddress, Amount: big.NewInt(4), Denom: testDenom, Status: data.StatusNotSent, UserID: user.ID}
+	transfer.ID, _ = storage.Transfers().CreateTransfer(ctx, transfer)
+	if err := ledger.New(storage).Claim(ctx, transfer); err != nil {
+		t.Fatalf("failed to claim transfer: %s", err)
+	}
+
+	message := requests.CancelTransferMessage(transfer.ID)
+	if w := serve(storage, cancelRouter(), cancelRequest(transfer.ID, sign(t, other, message))); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(storage, cancelRouter(), cancelRequest(transfer.ID, sign(t, key, message))); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(storage, cancelRouter(), cancelRequest(transfer.ID, sign(t, key, message))); w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	cancelled, _ := storage.Transfers().Get(ctx, transfer.ID)
+	if cancelled.Status != data.StatusCancelled {
+		t.Fatalf("expected status cancelled, got %s", cancelled.Status)
+	}
+
+	updated, _ := storage.Users().GetUserById(ctx, user.ID)
+	if updated.Amount.Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("expected amount to be credited back, got %s", updated.Amount)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/services/fees"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// GetQuote shows the fee charged from the transfer of the amount and the net amount converted to the payout
+func GetQuote(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewGetQuoteRequest(r)
+	if err != nil {
+		log.WithError(err).Debug("failed to parse get quote request")
+		render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", err.Error())))
+		return
+	}
+
+	binanceToken, ok := ctx.Config(r).BinanceToken(request.Denom)
+	if !ok {
+		render.Respond(w, http.StatusBadRequest, render.Message("unsupported denom"))
+		return
+	}
+
+	amount, amountErr := request.ParseAmount(binanceToken)
+	if amountErr != nil {
+		log.WithField("code", amountErr.Code).Debug(amountErr.Message)
+		respondAmountError(w, *amountErr)
+		return
+	}
+
+	quote, err := fees.New(ctx.Config(r), ctx.Storage(r)).Quote(request.Denom, amount)
+	if errors.Is(err, fees.ErrNotCovered) {
+		feeErr := requests.FeeError(quote.Fee, binanceToken)
+		log.WithField("code", feeErr.Code).Debug(feeErr.Message)
+		respondAmountError(w, feeErr)
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to quote fee")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	render.Respond(w, http.StatusOK, render.Message(quote.ToReturn()))
+}
+
+// GetFees returns fees accumulated by denoms
+func GetFees(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	balances, err := fees.New(ctx.Config(r), ctx.Storage(r)).Balances(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to get fees balances")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(balances))
+	for _, balance := range balances {
+		result = append(result, balance.ToReturn())
+	}
+	render.Respond(w, http.StatusOK, render.Message(result))
+}
+
+// SweepFees moves all the accumulated fees of the denom out of the bridge
+func SweepFees(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewSweepFeesRequest(r)
+	if err != nil {
+		if verr, ok := err.(validation.Errors); ok {
+			log.WithError(verr).Debug("failed to parse sweep fees request")
+			render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", verr.Error())))
+			return
+		}
+		log.WithError(err).Debug("failed to decode sweep fees request")
+		render.Respond(w, http.StatusBadRequest, render.Message("failed to decode request body"))
+		return
+	}
+
+	swept, err := fees.New(ctx.Config(r), ctx.Storage(r)).Sweep(r.Context(), request.Denom, ctx.Operator(r), request.Memo)
+	if err != nil {
+		log.WithError(err).Error("failed to sweep fees")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	if swept.Sign() == 0 {
+		render.Respond(w, http.StatusConflict, render.Message(fmt.Sprintf("no %s fees to sweep", request.Denom)))
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"operator": ctx.Operator(r),
+		"denom":    request.Denom,
+		"amount":   swept.String(),
+	}).Warn("Fees swept")
+	render.Respond(w, http.StatusOK, render.Message(map[string]interface{}{
+		"denom":  request.Denom,
+		"amount": swept.String(),
+	}))
+}
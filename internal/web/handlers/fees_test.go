@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/bsc-bridge-svc/internal/web/admin"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	"github.com/go-chi/chi"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// feesConfig charges 0.1 odin and 1% of the transfer
+type feesConfig struct {
+	testConfig
+}
+
+func (feesConfig) Fee(denom string) (config.FeePolicy, bool) {
+	return config.FeePolicy{Fixed: big.NewInt(100_000_000), Percent: big.NewRat(1, 1)}, denom == testDenom
+}
+
+func feesRouter() http.HandlerFunc {
+	router := chi.NewRouter()
+	router.Use(admin.Middleware(admin.RoleFees))
+	router.Get("/", GetFees)
+	router.Post("/sweep", SweepFees)
+	return router.ServeHTTP
+}
+
+func TestGetQuote(t *testing.T) {
+	storage, _ := newTestStorage(t, 0)
+
+	w := serveConfig(feesConfig{}, storage, GetQuote, httptest.NewRequest(http.MethodGet, "/bsc/quote?denom=odin&amount=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Message struct {
+			Amount string `json:"amount"`
+			Fee    string `json:"fee"`
+			Net    string `json:"net"`
+		} `json:"message"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if response.Message.Amount != "2000000000" || response.Message.Fee != "120000000" || response.Message.Net != "1880000000" {
+		t.Fatalf("unexpected quote: %+v", response.Message)
+	}
+
+	w = serveConfig(feesConfig{}, storage, GetQuote, httptest.NewRequest(http.MethodGet, "/bsc/quote?denom=odin&amount=0.1", nil))
+	assertFeeError(t, w)
+}
+
+// assertFeeError checks that 0.1 odin is rejected for not covering its fee of 0.101 odin
+func assertFeeError(t *testing.T, w *httptest.ResponseRecorder) {
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Error struct {
+			Code  string  `json:"code"`
+			Limit *string `json:"limit"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if response.Error.Code != requests.CodeBelowFee || response.Error.Limit == nil || *response.Error.Limit != "0.101" {
+		t.Fatalf("unexpected error: %s", w.Body.String())
+	}
+}
+
+func TestGetUser_Fee(t *testing.T) {
+	storage, _ := newTestStorage(t, 10_000_000_000)
+
+	w := serveConfig(feesConfig{}, storage, GetUser, exchangeRequest("2"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Quote struct {
+			Fee string `json:"fee"`
+			Net string `json:"net"`
+		} `json:"quote"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if response.Quote.Fee != "120000000" || response.Quote.Net != "1880000000" {
+		t.Fatalf("unexpected quote: %+v", response.Quote)
+	}
+
+	// the quoted fee is stored, so it is charged on the payout
+	transfers, _ := storage.Transfers().SelectStatus(context.Background(), data.StatusNotSent)
+	if len(transfers) != 1 || transfers[0].Fee == nil || transfers[0].Fee.Cmp(big.NewInt(120_000_000)) != 0 {
+		t.Fatalf("expected transfer with the quoted fee, got %+v", transfers)
+	}
+}
+
+func TestGetUser_FeeNotCovered(t *testing.T) {
+	storage, _ := newTestStorage(t, 10_000_000_000)
+
+	w := serveConfig(feesConfig{}, storage, GetUser, exchangeRequest("0.1"))
+	assertFeeError(t, w)
+
+	transfers, _ := storage.Transfers().SelectStatus(context.Background(), data.StatusNotSent)
+	if len(transfers) != 0 {
+		t.Fatalf("expected no transfers, got %d", len(transfers))
+	}
+}
+
+func TestSweepFees(t *testing.T) {
+	storage, user := newTestStorage(t, 10_000_000_000)
+	transfer := data.Transfer{
+		Amount: big.NewInt(2_000_000_000),
+		Denom:  testDenom,
+		UserID: user.ID,
+		Fee:    big.NewInt(120_000_000),
+	}
+	if err := ledger.New(storage).Claim(context.Background(), transfer); err != nil {
+		t.Fatalf("failed to claim transfer: %s", err)
+	}
+	if err := ledger.New(storage).Payout(context.Background(), transfer); err != nil {
+		t.Fatalf("failed to post payout: %s", err)
+	}
+
+	w := serveConfig(feesConfig{}, storage, feesRouter(), adminRequest(http.MethodGet, "/", "", testAdminToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Message []struct {
+			Denom  string `json:"denom"`
+			Amount string `json:"amount"`
+		} `json:"message"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if len(response.Message) != 1 || response.Message[0].Amount != "120000000" {
+		t.Fatalf("unexpected fees: %+v", response.Message)
+	}
+
+	sweep := `{"denom":"` + testDenom + `","memo":"cold wallet"}`
+	if w := serveConfig(feesConfig{}, storage, feesRouter(), adminRequest(http.MethodPost, "/sweep", sweep, testAdminToken)); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serveConfig(feesConfig{}, storage, feesRouter(), adminRequest(http.MethodPost, "/sweep", sweep, testAdminToken)); w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	swept, _ := storage.Ledger().SelectBalances(context.Background(), data.AccountSweptFees)
+	if len(swept) != 1 || swept[0].Amount.Cmp(big.NewInt(120_000_000)) != 0 {
+		t.Fatalf("unexpected swept fees: %+v", swept)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// GetTransfers lists transfers of a single user, selected by the binance or odin address
+func GetTransfers(w http.ResponseWriter, r *http.Request) {
+	getTransfers(w, r, true)
+}
+
+// GetAdminTransfers lists transfers of all users with the internal details
+func GetAdminTransfers(w http.ResponseWriter, r *http.Request) {
+	getTransfers(w, r, false)
+}
+
+func getTransfers(w http.ResponseWriter, r *http.Request, scoped bool) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewGetTransfersRequest(r, scoped)
+	if err != nil {
+		log.WithError(err).Debug("failed to parse get transfers request")
+		render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", err.Error())))
+		return
+	}
+
+	query := ctx.Transfers(r)
+	if len(request.Statuses) > 0 {
+		query = query.FilterByStatus(request.Statuses...)
+	}
+	if len(request.Denoms) > 0 {
+		query = query.FilterByDenom(request.Denoms...)
+	}
+	if request.OdinAddress != "" {
+		query = query.FilterByAddress(request.OdinAddress)
+	}
+	if request.BinanceAddress != "" {
+		query = query.FilterByUserAddress(request.BinanceAddress)
+	}
+	query = query.FilterByCreatedAt(request.From, request.To)
+
+	transfers, err := query.Page(request.Page).Select(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to select transfers")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(transfers))
+	for _, transfer := range transfers {
+		if scoped {
+			result = append(result, transfer.ToPublicReturn())
+		} else {
+			result = append(result, transfer.ToReturn())
+		}
+	}
+
+	var nextCursor *int64
+	if uint64(len(transfers)) == request.Page.Limit {
+		nextCursor = &transfers[len(transfers)-1].ID
+	}
+
+	render.Respond(w, http.StatusOK, render.Page(result, nextCursor))
+}
+
+// GetTransfer returns the transfer with its history to the owner, selected by the binance or odin address
+func GetTransfer(w http.ResponseWriter, r *http.Request) {
+	getTransfer(w, r, true)
+}
+
+// GetAdminTransfer returns any transfer with its history and the internal details
+func GetAdminTransfer(w http.ResponseWriter, r *http.Request) {
+	getTransfer(w, r, false)
+}
+
+func getTransfer(w http.ResponseWriter, r *http.Request, scoped bool) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewGetTransferRequest(r, scoped)
+	if err != nil {
+		if verr, ok := err.(validation.Errors); ok {
+			log.WithError(verr).Debug("failed to parse get transfer request")
+			render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", verr.Error())))
+			return
+		}
+		log.WithError(err).Error("something bad happened")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened parsing the request"))
+		return
+	}
+
+	transfer, err := ctx.Transfers(r).Get(r.Context(), request.ID)
+	if err != nil {
+		log.WithError(err).Error("failed to get transfer")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	if transfer != nil && request.Scoped() {
+		owned, err := ownedBy(r, *transfer, request.Owner)
+		if err != nil {
+			log.WithError(err).Error("failed to get owner of transfer")
+			render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+			return
+		}
+		// transfers of other users are not distinguished from missing ones
+		if !owned {
+			transfer = nil
+		}
+	}
+	if transfer == nil {
+		render.Respond(w, http.StatusNotFound, render.Message(fmt.Sprintf("transfer not found: %d", request.ID)))
+		return
+	}
+
+	events, err := ctx.Transfers(r).SelectEvents(r.Context(), transfer.ID)
+	if err != nil {
+		log.WithError(err).Error("failed to select transfer events")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	history := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		if scoped {
+			history = append(history, event.ToPublicReturn())
+		} else {
+			history = append(history, event.ToReturn())
+		}
+	}
+
+	result := transfer.ToReturn()
+	if scoped {
+		result = transfer.ToPublicReturn()
+	}
+	result["events"] = history
+	render.Respond(w, http.StatusOK, render.Message(result))
+}
+
+// ownedBy checks whether the transfer matches all the addresses of the owner
+func ownedBy(r *http.Request, transfer data.Transfer, owner requests.Owner) (bool, error) {
+	if owner.OdinAddress != "" && owner.OdinAddress != transfer.Address {
+		return false, nil
+	}
+	if owner.BinanceAddress == "" {
+		return true, nil
+	}
+
+	user, err := ctx.Users(r).GetUserById(r.Context(), transfer.UserID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get user")
+	}
+	return user != nil && requests.CanonicalAddress(user.Address) == owner.BinanceAddress, nil
+}
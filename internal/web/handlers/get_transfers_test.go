@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/go-chi/chi"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func transfersRouter() http.HandlerFunc {
+	router := chi.NewRouter()
+	router.Get("/", GetTransfers)
+	router.Get(fmt.Sprintf("/{%s}", web.IDRequestKey), GetTransfer)
+	return router.ServeHTTP
+}
+
+func TestGetTransfers_Scoped(t *testing.T) {
+	storage, user := newTestStorage(t, 10)
+	lastError := "rpc error: internal"
+	transfer := data.Transfer{
+		Address:   testOdinAddress,
+		Amount:    big.NewInt(4),
+		Denom:     testDenom,
+		Status:    data.StatusNotSent,
+		UserID:    user.ID,
+		LastError: &lastError,
+	}
+	transfer.ID, _ = storage.Transfers().CreateTransfer(context.Background(), transfer)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		return serve(storage, transfersRouter(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	// transfers of all users are not listed publicly
+	if w := get("/"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without the owner, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get(fmt.Sprintf("/%d", transfer.ID)); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without the owner, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get(fmt.Sprintf("/%d?binance_address=0x0000000000000000000000000000000000000001", transfer.ID)); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for another owner, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get(fmt.Sprintf("/%d?odin_address=odin1other", transfer.ID)); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for another owner, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := get(fmt.Sprintf("/%d?binance_address=%s", transfer.ID, testBinanceAddress))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var single struct {
+		Message map[string]interface{} `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &single); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if _, ok := single.Message["last_error"]; ok {
+		t.Fatalf("expected last error to be hidden, got %s", w.Body.String())
+	}
+
+	w = get("/?odin_address=" + testOdinAddress)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Message []map[string]interface{} `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if len(page.Message) != 1 {
+		t.Fatalf("expected 1 transfer, got %s", w.Body.String())
+	}
+	if _, ok := page.Message[0]["last_error"]; ok {
+		t.Fatalf("expected last error to be hidden, got %s", w.Body.String())
+	}
+}
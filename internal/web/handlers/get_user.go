@@ -3,19 +3,43 @@ package handlers
 import (
 	"fmt"
 	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/fees"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/bsc-bridge-svc/internal/services/limits"
+	"github.com/bsc-bridge-svc/internal/services/pause"
+	"github.com/bsc-bridge-svc/internal/services/review"
 	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/idempotency"
 	"github.com/bsc-bridge-svc/internal/web/render"
 	"github.com/bsc-bridge-svc/internal/web/requests"
 	"github.com/bsc-bridge-svc/internal/web/utils"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"math"
 	"math/big"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+var errInsufficientFunds = errors.New("insufficient funds")
+
 func GetUser(w http.ResponseWriter, r *http.Request) {
 	log := ctx.Log(r)
 
+	paused, err := pause.New(ctx.Storage(r)).State(r.Context(), data.PauseClaims)
+	if err != nil {
+		log.WithError(err).Error("failed to get pause of claims")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	if paused.Tripped() {
+		render.Respond(w, http.StatusServiceUnavailable, render.Message("claims are paused, try again later"))
+		return
+	}
+
 	request, err := requests.NewGetUserRequest(r)
 	if err != nil {
 		if verr, ok := err.(validation.Errors); ok {
@@ -28,7 +52,7 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := ctx.Users(r).GetUser(request.BinanceAddress, request.Denom)
+	user, err := ctx.Users(r).GetUser(r.Context(), request.BinanceAddress, request.Denom)
 	if err != nil {
 		log.WithError(err).Error("failed to get user")
 		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
@@ -42,6 +66,14 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	binanceLimit, odinLimit := ctx.Config(r).AddressLimits(request.Denom)
+	amountToWithdraw, amountErr := request.ParseAmount(binanceToken, binanceLimit, odinLimit)
+	if amountErr != nil {
+		log.WithField("code", amountErr.Code).Debug(amountErr.Message)
+		respondAmountError(w, *amountErr)
+		return
+	}
+
 	if user == nil {
 		balanceAmount, err := ctx.Bridge(r).GetAccount(binanceToken.Address, ethcommon.HexToAddress(request.BinanceAddress))
 		if err != nil {
@@ -62,62 +94,149 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	amountToWithdraw, err := utils.ParseAmount(request.Amount, binanceToken.Precision)
+	// the fee is fixed at the claim, so later changes of the policy do not affect the transfer
+	quote, err := fees.New(ctx.Config(r), ctx.Storage(r)).Quote(request.Denom, amountToWithdraw)
+	if errors.Is(err, fees.ErrNotCovered) {
+		feeErr := requests.FeeError(quote.Fee, binanceToken)
+		log.WithField("code", feeErr.Code).Debug(feeErr.Message)
+		respondAmountError(w, feeErr)
+		return
+	}
 	if err != nil {
-		log.WithError(err).Debug("failed to parse amountToWithdraw")
-		render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", err.Error())))
+		log.WithError(err).Error("failed to quote fee")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
 		return
 	}
 
 	log.WithField("request_amount", amountToWithdraw).Info("Parsed request amountToWithdraw")
-	log.WithField("user_amount", user.Amount).Info("User amount")
 
-	bigAmount, ok := new(big.Int).SetString(user.Amount, 10)
-	if !ok {
-		log.WithError(err).Debug(fmt.Sprintf("failed to parse amount: %s", user.Amount))
-		render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("failed to parse amount: %s", user.Amount)))
-		return
-	}
-	remainder, neg := utils.SufficientAmount(bigAmount, amountToWithdraw)
-	if neg {
-		log.WithError(err).Debug("insufficient funds")
-		render.Respond(w, http.StatusBadRequest, render.Message("insufficient funds"))
-		return
-	}
-
-	err = ctx.Transfers(r).CreateTransfer(data.Transfer{
+	transfer := data.Transfer{
 		Address: request.OdinAddress,
-		Amount:  amountToWithdraw.String(),
+		Amount:  amountToWithdraw,
 		Denom:   request.Denom,
 		Status:  data.StatusNotSent,
 		UserID:  user.ID,
+		Fee:     quote.Fee,
+	}
+
+	// the transfer is not sent until it is approved by an operator
+	holdReason := review.New(ctx.Config(r), ctx.Storage(r)).Check(transfer, *user)
+	if holdReason != "" {
+		log.WithField("reason", holdReason).Info("Transfer is held for the manual review")
+		transfer.Status = data.StatusOnHold
+	}
+
+	var violation *limits.Violation
+	var remainder *big.Int
+	err = ctx.Storage(r).Transaction(r.Context(), func(tx postgres.Storage) error {
+		// limits are checked against the transfers committed before, the addresses are locked until the transfer is created
+		violation, err = limits.New(ctx.Config(r), tx).Check(r.Context(), transfer, *user)
+		if err != nil {
+			return err
+		}
+		if violation != nil {
+			return violation
+		}
+
+		// the balance is read under the lock, so concurrent claims do not overdraw it
+		locked, err := tx.Users().GetUserForUpdate(r.Context(), user.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get user for update")
+		}
+		log.WithField("user_amount", locked.Amount).Info("User amount")
+
+		var neg bool
+		if remainder, neg = utils.SufficientAmount(locked.Amount, amountToWithdraw); neg {
+			return errInsufficientFunds
+		}
+
+		transfer.ID, err = tx.Transfers().CreateTransfer(r.Context(), transfer)
+		if err != nil {
+			return errors.Wrap(err, "failed to create transfer")
+		}
+		if err := ledger.New(tx).Claim(r.Context(), transfer); err != nil {
+			return err
+		}
+		if holdReason != "" {
+			if err := review.New(ctx.Config(r), tx).Hold(r.Context(), transfer, holdReason); err != nil {
+				return err
+			}
+		}
+		// the transfer is created only once for the idempotency key, even if its response is lost
+		return idempotency.Commit(r, tx)
 	})
+	if violation != nil {
+		log.WithField("code", violation.Code).Debug(violation.Message)
+		respondViolation(w, *violation)
+		return
+	}
+	if errors.Is(err, errInsufficientFunds) {
+		log.Debug("insufficient funds")
+		render.Respond(w, http.StatusBadRequest, render.Message("insufficient funds"))
+		return
+	}
+	if errors.Is(err, postgres.ErrReservationLost) {
+		log.WithError(err).Warn("idempotency key reservation expired")
+		render.Respond(w, http.StatusConflict, render.Message("request with the same idempotency key is in progress"))
+		return
+	}
 	if err != nil {
-		log.WithError(err).Debug("failed to create transfer")
+		log.WithError(err).Error("failed to create transfer")
 		render.Respond(w, http.StatusInternalServerError, render.Message("failed to create transfer"))
 		return
 	}
 
-	user.Amount = remainder.String()
-	if err := ctx.Users(r).UpdateUser(*user); err != nil {
-		log.WithError(err).Error("failed to update amount")
-		render.Respond(w, http.StatusInternalServerError, render.Message("failed to update amount"))
-		return
+	user.Amount = remainder
+	response := render.Message(user.ToReturn())
+	response["quote"] = quote.ToReturn()
+	render.Respond(w, http.StatusOK, response)
+}
+
+// respondAmountError renders the rule the requested amount violates
+func respondAmountError(w http.ResponseWriter, amountErr requests.AmountError) {
+	render.Respond(w, http.StatusBadRequest, map[string]interface{}{
+		"message": fmt.Sprintf("request was invalid in some way: %s", amountErr.Message),
+		"error":   amountErr.ToReturn(),
+	})
+}
+
+// respondViolation renders the violated limit, the claims which are allowed later are rejected as too many requests
+func respondViolation(w http.ResponseWriter, violation limits.Violation) {
+	status := http.StatusBadRequest
+	if violation.Code == limits.CodeCooldown || violation.Code == limits.CodeMaxTotal {
+		status = http.StatusTooManyRequests
+	}
+	if violation.RetryAt != nil {
+		retryAfter := int64(math.Ceil(time.Until(*violation.RetryAt).Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
 	}
 
-	render.Respond(w, http.StatusOK, render.Message(user.ToReturn()))
+	render.Respond(w, status, map[string]interface{}{
+		"message": fmt.Sprintf("claim exceeds the limit: %s", violation.Message),
+		"error":   violation.ToReturn(),
+	})
 }
 
 func saveBalance(r *http.Request, request *requests.GetUserRequest, balanceAmount *big.Int) (*data.User, error) {
 	user := data.User{
 		Address: request.BinanceAddress,
-		Amount:  balanceAmount.String(),
+		Amount:  new(big.Int),
 		Denom:   request.Denom,
 	}
-	id, err := ctx.Users(r).CreateUser(user)
-	user.ID = id
+	err := ctx.Storage(r).Transaction(r.Context(), func(tx postgres.Storage) error {
+		var err error
+		user.ID, err = tx.Users().CreateUser(r.Context(), user)
+		if err != nil {
+			return err
+		}
+		return ledger.New(tx).Open(r.Context(), user, balanceAmount)
+	})
 	if err != nil {
 		return nil, err
 	}
+	user.Amount = balanceAmount
 	return &user, nil
 }
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/memory"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/services/ledger"
+	"github.com/bsc-bridge-svc/internal/web/admin"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testBinanceAddress = "0x2F318C334780961FB129D2A6c30D0763d9a5C970"
+	testOdinAddress    = "odin1xyz"
+	testDenom          = "odin"
+	testOperator       = "alice"
+	testAdminToken     = "secret"
+	testReviewer       = "bob"
+	testReviewerToken  = "review-secret"
+)
+
+// testConfig overrides only the settings used by handlers
+type testConfig struct {
+	config.Config
+}
+
+func (testConfig) BinanceToken(denom string) (config.BinanceToken, bool) {
+	if denom != testDenom {
+		return config.BinanceToken{}, false
+	}
+	return config.BinanceToken{Precision: 9}, true
+}
+
+// transfers above 5 odin are held for the review
+func (testConfig) ReviewThreshold(denom string) (*big.Int, bool) {
+	return big.NewInt(5_000_000_000), denom == testDenom
+}
+
+func (testConfig) ReviewFlagged(string) bool {
+	return false
+}
+
+func (testConfig) AdminOperator(token string) (config.Operator, bool) {
+	switch token {
+	case testAdminToken:
+		return config.Operator{
+			Name:  testOperator,
+			Roles: []string{admin.RoleReview, admin.RolePauses, admin.RoleBreaker, admin.RoleFees},
+		}, true
+	case testReviewerToken:
+		return config.Operator{Name: testReviewer, Roles: []string{admin.RoleReview}}, true
+	}
+	return config.Operator{}, false
+}
+
+func (testConfig) BreakerWindow() time.Duration {
+	return time.Hour
+}
+
+func (testConfig) BreakerBinanceLimits() map[string]config.Limit {
+	return map[string]config.Limit{testDenom: {Cap: big.NewInt(100_000_000_000)}}
+}
+
+func (testConfig) BreakerOdinLimits() map[string]config.Limit {
+	return nil
+}
+
+func (testConfig) AddressLimits(string) (binance, odin config.AddressLimit) {
+	return config.AddressLimit{}, config.AddressLimit{}
+}
+
+func (testConfig) Fee(string) (config.FeePolicy, bool) {
+	return config.FeePolicy{}, false
+}
+
+func newTestStorage(t *testing.T, balance int64) (postgres.Storage, data.User) {
+	storage := memory.NewStorage()
+	user := data.User{Address: requests.CanonicalAddress(testBinanceAddress), Amount: new(big.Int), Denom: testDenom}
+
+	var err error
+	user.ID, err = storage.Users().CreateUser(context.Background(), user)
+	if err != nil {
+		t.Fatalf("failed to create user: %s", err)
+	}
+	if err := ledger.New(storage).Open(context.Background(), user, big.NewInt(balance)); err != nil {
+		t.Fatalf("failed to open balance: %s", err)
+	}
+	return storage, user
+}
+
+func serve(storage postgres.Storage, handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	return serveConfig(testConfig{}, storage, handler, r)
+}
+
+func serveConfig(cfg config.Config, storage postgres.Storage, handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	w := httptest.NewRecorder()
+	ctx.Middleware(
+		ctx.CtxLog(log),
+		ctx.CtxConfig(cfg),
+		ctx.CtxUsers(storage.Users()),
+		ctx.CtxTransfers(storage.Transfers()),
+		ctx.CtxStorage(storage),
+	)(handler).ServeHTTP(w, r)
+	return w
+}
+
+func exchangeRequest(amount string) *http.Request {
+	return exchangeRequestFrom(testBinanceAddress, amount)
+}
+
+func exchangeRequestFrom(address, amount string) *http.Request {
+	body := `{"binance_address":"` + address + `","odin_address":"` + testOdinAddress +
+		`","amount":"` + amount + `","denom":"` + testDenom + `"}`
+	return httptest.NewRequest(http.MethodPost, "/bsc/exchange", strings.NewReader(body))
+}
+
+func TestGetUser(t *testing.T) {
+	storage, user := newTestStorage(t, 10_000_000_000)
+
+	w := serve(storage, GetUser, exchangeRequest("1.5"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	updated, _ := storage.Users().GetUserById(context.Background(), user.ID)
+	if updated.Amount.Cmp(big.NewInt(8_500_000_000)) != 0 {
+		t.Fatalf("expected remaining balance 8500000000, got %s", updated.Amount)
+	}
+
+	transfers, _ := storage.Transfers().SelectStatus(context.Background(), data.StatusNotSent)
+	if len(transfers) != 1 {
+		t.Fatalf("expected 1 transfer, got %d", len(transfers))
+	}
+	if transfers[0].Address != testOdinAddress || transfers[0].Amount.Cmp(big.NewInt(1_500_000_000)) != 0 {
+		t.Fatalf("unexpected transfer: %+v", transfers[0])
+	}
+
+	changed, _ := storage.Ledger().RebuildBalances(context.Background())
+	if changed != 0 {
+		t.Fatalf("cached balance differs from the ledger")
+	}
+}
+
+func TestGetUser_InsufficientFunds(t *testing.T) {
+	storage, user := newTestStorage(t, 1_000_000_000)
+
+	w := serve(storage, GetUser, exchangeRequest("2"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	updated, _ := storage.Users().GetUserById(context.Background(), user.ID)
+	if updated.Amount.Cmp(big.NewInt(1_000_000_000)) != 0 {
+		t.Fatalf("balance must not change, got %s", updated.Amount)
+	}
+
+	transfers, _ := storage.Transfers().Select(context.Background())
+	if len(transfers) != 0 {
+		t.Fatalf("expected no transfers, got %d", len(transfers))
+	}
+}
+
+func TestGetUser_ConcurrentClaims(t *testing.T) {
+	storage, user := newTestStorage(t, 2_000_000_000)
+
+	// both claims may read the balance before any of them is created, only one of them is covered
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			codes <- serve(storage, GetUser, exchangeRequest("1.5")).Code
+		}()
+	}
+	first, second := <-codes, <-codes
+	if first+second != http.StatusOK+http.StatusBadRequest {
+		t.Fatalf("expected statuses 200 and 400, got %d and %d", first, second)
+	}
+
+	updated, _ := storage.Users().GetUserById(context.Background(), user.ID)
+	if updated.Amount.Cmp(big.NewInt(500_000_000)) != 0 {
+		t.Fatalf("expected remaining balance 500000000, got %s", updated.Amount)
+	}
+}
+
+func TestGetUser_InvalidAmount(t *testing.T) {
+	storage, _ := newTestStorage(t, 10_000_000_000)
+	// claims of the binance address are 1-3 odin
+	cfg := limitsConfig{}
+
+	cases := []struct {
+		amount string
+		code   string
+		limit  string
+	}{
+		{"abc", requests.CodeInvalidAmount, ""},
+		{"1e3", requests.CodeInvalidAmount, ""},
+		{"-1", requests.CodeNotPositive, ""},
+		{"0", requests.CodeNotPositive, ""},
+		{"1.0000000001", requests.CodeTooManyDecimals, "9"},
+		{"0.5", requests.CodeBelowMinClaim, "1"},
+		{"3.000000001", requests.CodeAboveMaxClaim, "3"},
+	}
+	for _, c := range cases {
+		w := serveConfig(cfg, storage, GetUser, exchangeRequest(c.amount))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("claim of %s: expected status 400, got %d: %s", c.amount, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Error struct {
+				Code  string  `json:"code"`
+				Field string  `json:"field"`
+				Limit *string `json:"limit"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %s", err)
+		}
+		limit := ""
+		if response.Error.Limit != nil {
+			limit = *response.Error.Limit
+		}
+		if response.Error.Code != c.code || response.Error.Field != "amount" || limit != c.limit {
+			t.Fatalf("claim of %s: unexpected error %+v", c.amount, response.Error)
+		}
+	}
+
+	transfers, _ := storage.Transfers().Select(context.Background())
+	if len(transfers) != 0 {
+		t.Fatalf("expected no transfers, got %d", len(transfers))
+	}
+}
+
+func TestGetUser_CanonicalAddress(t *testing.T) {
+	storage, user := newTestStorage(t, 10_000_000_000)
+
+	// the same holder in any case is the same user, so the balance is claimed once
+	for _, address := range []string{testBinanceAddress, strings.ToLower(testBinanceAddress), strings.ToUpper(testBinanceAddress[2:])} {
+		if w := serve(storage, GetUser, exchangeRequestFrom(address, "1")); w.Code != http.StatusOK {
+			t.Fatalf("claim from %s: expected status 200, got %d: %s", address, w.Code, w.Body.String())
+		}
+	}
+
+	updated, _ := storage.Users().GetUserById(context.Background(), user.ID)
+	if updated.Amount.Cmp(big.NewInt(7_000_000_000)) != 0 {
+		t.Fatalf("expected remaining balance 7000000000, got %s", updated.Amount)
+	}
+}
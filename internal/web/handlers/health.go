@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"net/http"
+)
+
+// GetHealth reports states of the background jobs, the service is unhealthy while any of them is crashed
+func GetHealth(w http.ResponseWriter, r *http.Request) {
+	jobs := ctx.Jobs(r)
+
+	states := jobs.States()
+	result := make([]map[string]interface{}, 0, len(states))
+	for _, state := range states {
+		result = append(result, state.ToReturn())
+	}
+
+	status := http.StatusOK
+	if !jobs.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	render.Respond(w, status, render.Message(result))
+}
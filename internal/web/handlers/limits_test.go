@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/services/limits"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// limitsConfig limits claims of the binance address to 1-3 odin, 3 odin a day once in a minute
+type limitsConfig struct {
+	testConfig
+	cooldown time.Duration
+}
+
+func (c limitsConfig) AddressLimits(string) (binance, odin config.AddressLimit) {
+	return config.AddressLimit{
+		MinClaim: big.NewInt(1_000_000_000),
+		MaxClaim: big.NewInt(3_000_000_000),
+		MaxTotal: big.NewInt(3_000_000_000),
+		Window:   24 * time.Hour,
+		Cooldown: c.cooldown,
+	}, config.AddressLimit{}
+}
+
+func TestGetUserLimits(t *testing.T) {
+	storage, _ := newTestStorage(t, 10_000_000_000)
+	cfg := limitsConfig{cooldown: time.Minute}
+
+	cases := []struct {
+		amount string
+		status int
+		code   string
+		retry  bool
+	}{
+		{"2", http.StatusOK, "", false},
+		{"1", http.StatusTooManyRequests, limits.CodeCooldown, true},
+	}
+	for _, c := range cases {
+		w := serveConfig(cfg, storage, GetUser, exchangeRequest(c.amount))
+		if w.Code != c.status {
+			t.Fatalf("claim of %s: expected status %d, got %d: %s", c.amount, c.status, w.Code, w.Body.String())
+		}
+		if c.code == "" {
+			continue
+		}
+
+		var response struct {
+			Error struct {
+				Code    string     `json:"code"`
+				Scope   string     `json:"scope"`
+				RetryAt *time.Time `json:"retry_at"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %s", err)
+		}
+		if response.Error.Code != c.code || response.Error.Scope != limits.ScopeBinanceAddress {
+			t.Fatalf("claim of %s: unexpected error %+v", c.amount, response.Error)
+		}
+		if c.retry != (response.Error.RetryAt != nil) || c.retry != (w.Header().Get("Retry-After") != "") {
+			t.Fatalf("claim of %s: unexpected retry %+v, %q", c.amount, response.Error, w.Header().Get("Retry-After"))
+		}
+	}
+
+	// the total within the day is exceeded without the cooldown, it is allowed once the first claim leaves the window
+	cfg.cooldown = 0
+	w := serveConfig(cfg, storage, GetUser, exchangeRequest("1.5"))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected status 429 with retry, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serveConfig(cfg, storage, GetUser, exchangeRequest("1")); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
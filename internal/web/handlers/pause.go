@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/services/pause"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"net/http"
+)
+
+func GetPauses(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	states, err := pause.New(ctx.Storage(r)).States(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to get pauses")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	render.Respond(w, http.StatusOK, render.Message(pausesToReturn(states)))
+}
+
+func Pause(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewPauseRequest(r)
+	if err != nil {
+		if verr, ok := err.(validation.Errors); ok {
+			log.WithError(verr).Debug("failed to parse pause request")
+			render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", verr.Error())))
+			return
+		}
+		log.WithError(err).Debug("failed to decode pause request")
+		render.Respond(w, http.StatusBadRequest, render.Message("failed to decode request body"))
+		return
+	}
+
+	paused, err := pause.New(ctx.Storage(r)).Pause(r.Context(), request.Target, ctx.Operator(r), request.Reason)
+	if err != nil {
+		log.WithError(err).Error("failed to pause")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	if !paused {
+		render.Respond(w, http.StatusConflict, render.Message(fmt.Sprintf("%s are already paused", request.Target)))
+		return
+	}
+
+	log.WithField("operator", ctx.Operator(r)).WithField("target", request.Target).Warn("Paused")
+	GetPauses(w, r)
+}
+
+func Resume(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewResumeRequest(r)
+	if err != nil {
+		log.WithError(err).Debug("failed to parse resume request")
+		render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", err.Error())))
+		return
+	}
+
+	resumed, err := pause.New(ctx.Storage(r)).Resume(r.Context(), request.Target, ctx.Operator(r))
+	if err != nil {
+		log.WithError(err).Error("failed to resume")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+	if !resumed {
+		render.Respond(w, http.StatusConflict, render.Message(fmt.Sprintf("%s are not paused", request.Target)))
+		return
+	}
+
+	log.WithField("operator", ctx.Operator(r)).WithField("target", request.Target).Warn("Resumed")
+	GetPauses(w, r)
+}
+
+func pausesToReturn(states []data.CircuitBreaker) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(states))
+	for _, state := range states {
+		item := state.ToReturn()
+		item["paused"] = state.Tripped()
+		result = append(result, item)
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/bsc-bridge-svc/internal/web/admin"
+	"github.com/go-chi/chi"
+	"net/http"
+	"testing"
+)
+
+func pauseRouter() http.HandlerFunc {
+	router := chi.NewRouter()
+	router.Use(admin.Middleware(admin.RolePauses))
+	router.Get("/", GetPauses)
+	router.Post(fmt.Sprintf("/{%s}/pause", web.TargetRequestKey), Pause)
+	router.Post(fmt.Sprintf("/{%s}/resume", web.TargetRequestKey), Resume)
+	return router.ServeHTTP
+}
+
+func TestPauseClaims(t *testing.T) {
+	storage, _ := newTestStorage(t, 10_000_000_000)
+
+	unknown := adminRequest(http.MethodPost, "/withdrawals/pause", `{"reason":"incident"}`, testAdminToken)
+	if w := serve(storage, pauseRouter(), unknown); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pause := adminRequest(http.MethodPost, "/claims/pause", `{"reason":"incident"}`, testAdminToken)
+	if w := serve(storage, pauseRouter(), pause); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	pause = adminRequest(http.MethodPost, "/claims/pause", `{"reason":"incident"}`, testAdminToken)
+	if w := serve(storage, pauseRouter(), pause); w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := serve(storage, GetUser, exchangeRequest("1")); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d: %s", w.Code, w.Body.String())
+	}
+
+	resume := adminRequest(http.MethodPost, "/claims/resume", "", testAdminToken)
+	if w := serve(storage, pauseRouter(), resume); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(storage, GetUser, exchangeRequest("1")); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
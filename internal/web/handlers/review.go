@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/services/review"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"github.com/bsc-bridge-svc/internal/web/requests"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+func GetHeldTransfers(w http.ResponseWriter, r *http.Request) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewGetHeldTransfersRequest(r)
+	if err != nil {
+		log.WithError(err).Debug("failed to parse get held transfers request")
+		render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", err.Error())))
+		return
+	}
+
+	transfers, err := review.New(ctx.Config(r), ctx.Storage(r)).Held(r.Context(), request.Page)
+	if err != nil {
+		log.WithError(err).Error("failed to select held transfers")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(transfers))
+	for _, transfer := range transfers {
+		reviews, err := ctx.Storage(r).Reviews().SelectReviews(r.Context(), transfer.ID)
+		if err != nil {
+			log.WithError(err).Error("failed to select transfer reviews")
+			render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+			return
+		}
+
+		item := transfer.ToReturn()
+		item["user_id"] = transfer.UserID
+		item["reviews"] = reviewsToReturn(reviews)
+		result = append(result, item)
+	}
+
+	var nextCursor *int64
+	if uint64(len(transfers)) == request.Page.Limit {
+		nextCursor = &transfers[len(transfers)-1].ID
+	}
+
+	render.Respond(w, http.StatusOK, render.Page(result, nextCursor))
+}
+
+func ApproveTransfer(w http.ResponseWriter, r *http.Request) {
+	reviewTransfer(w, r, (*review.Service).Approve)
+}
+
+func RejectTransfer(w http.ResponseWriter, r *http.Request) {
+	reviewTransfer(w, r, (*review.Service).Reject)
+}
+
+// decision is the method of review service applied to the held transfer
+type decision func(s *review.Service, c context.Context, id int64, operator, reason string) (*data.Transfer, error)
+
+func reviewTransfer(w http.ResponseWriter, r *http.Request, decide decision) {
+	log := ctx.Log(r)
+
+	request, err := requests.NewReviewTransferRequest(r)
+	if err != nil {
+		if verr, ok := err.(validation.Errors); ok {
+			log.WithError(verr).Debug("failed to parse review transfer request")
+			render.Respond(w, http.StatusBadRequest, render.Message(fmt.Sprintf("request was invalid in some way: %s", verr.Error())))
+			return
+		}
+		log.WithError(err).Debug("failed to decode review transfer request")
+		render.Respond(w, http.StatusBadRequest, render.Message("failed to decode request body"))
+		return
+	}
+
+	operator := ctx.Operator(r)
+	transfer, err := decide(review.New(ctx.Config(r), ctx.Storage(r)), r.Context(), request.ID, operator, request.Reason)
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		render.Respond(w, http.StatusNotFound, render.Message(fmt.Sprintf("transfer not found: %d", request.ID)))
+		return
+	case errors.Is(err, review.ErrNotHeld):
+		render.Respond(w, http.StatusConflict, render.Message("transfer is not on hold"))
+		return
+	case err != nil:
+		log.WithError(err).Error("failed to review transfer")
+		render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+		return
+	}
+
+	log.WithField("transfer_id", transfer.ID).WithField("operator", operator).
+		WithField("status", transfer.Status).Info("Transfer reviewed")
+	render.Respond(w, http.StatusOK, render.Message(transfer.ToReturn()))
+}
+
+func reviewsToReturn(reviews []data.TransferReview) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(reviews))
+	for _, transferReview := range reviews {
+		result = append(result, transferReview.ToReturn())
+	}
+	return result
+}
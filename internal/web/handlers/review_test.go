@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/bsc-bridge-svc/internal/web/admin"
+	"github.com/go-chi/chi"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func adminRouter() http.HandlerFunc {
+	router := chi.NewRouter()
+	router.Use(admin.Middleware(admin.RoleReview))
+	router.Get("/held", GetHeldTransfers)
+	router.Post(fmt.Sprintf("/{%s}/approve", web.IDRequestKey), ApproveTransfer)
+	router.Post(fmt.Sprintf("/{%s}/reject", web.IDRequestKey), RejectTransfer)
+	return router.ServeHTTP
+}
+
+func adminRequest(method, target, body, token string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestReview(t *testing.T) {
+	storage, user := newTestStorage(t, 20_000_000_000)
+
+	for _, amount := range []string{"6", "7"} {
+		if w := serve(storage, GetUser, exchangeRequest(amount)); w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	held, _ := storage.Transfers().SelectStatus(context.Background(), data.StatusOnHold)
+	if len(held) != 2 {
+		t.Fatalf("expected 2 held transfers, got %d", len(held))
+	}
+
+	if w := serve(storage, adminRouter(), adminRequest(http.MethodGet, "/held", "", "wrong")); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", w.Code)
+	}
+	if w := serve(storage, adminRouter(), adminRequest(http.MethodGet, "/held", "", testAdminToken)); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	approve := adminRequest(http.MethodPost, fmt.Sprintf("/%d/approve", held[0].ID), `{"reason":"known customer"}`, testAdminToken)
+	if w := serve(storage, adminRouter(), approve); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	reject := adminRequest(http.MethodPost, fmt.Sprintf("/%d/reject", held[1].ID), `{"reason":"suspicious"}`, testAdminToken)
+	if w := serve(storage, adminRouter(), reject); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// the decision is made only once
+	reject = adminRequest(http.MethodPost, fmt.Sprintf("/%d/reject", held[0].ID), `{"reason":"changed mind"}`, testAdminToken)
+	if w := serve(storage, adminRouter(), reject); w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	approved, _ := storage.Transfers().Get(context.Background(), held[0].ID)
+	rejected, _ := storage.Transfers().Get(context.Background(), held[1].ID)
+	if approved.Status != data.StatusNotSent || rejected.Status != data.StatusRefunded {
+		t.Fatalf("unexpected statuses: %s, %s", approved.Status, rejected.Status)
+	}
+
+	reviews, _ := storage.Reviews().SelectReviews(context.Background(), rejected.ID)
+	if len(reviews) != 2 || reviews[1].Decision != data.ReviewReject || reviews[1].Operator != testOperator {
+		t.Fatalf("unexpected reviews: %+v", reviews)
+	}
+
+	updated, _ := storage.Users().GetUserById(context.Background(), user.ID)
+	if updated.Amount.Cmp(big.NewInt(14_000_000_000)) != 0 {
+		t.Fatalf("expected rejected amount to be refunded, got %s", updated.Amount)
+	}
+}
@@ -0,0 +1,191 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/bsc-bridge-svc/internal/web/render"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	ctxReservation = "ctxReservation"
+)
+
+// Scope returns the owner of the request by its body, keys of different owners do not collide
+type Scope func(body []byte) string
+
+// Middleware replays the stored response for requests with the same Idempotency-Key header within the scope
+func Middleware(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return middleware(scope, next)
+	}
+}
+
+func middleware(scope Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(KeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log := ctx.Log(r).WithField("idempotency_key", key)
+		if len(key) > maxKeyLength {
+			render.Respond(w, http.StatusBadRequest, render.Message("idempotency key is too long"))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.WithError(err).Debug("failed to read request body")
+			render.Respond(w, http.StatusBadRequest, render.Message("failed to read request body"))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		token, err := newReservation()
+		if err != nil {
+			log.WithError(err).Error("failed to generate idempotency key reservation")
+			render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+			return
+		}
+
+		keys := ctx.Storage(r).IdempotencyKeys()
+		reservation := data.IdempotencyKey{
+			Key:         scope(body) + "/" + key,
+			RequestHash: hashRequest(r, body),
+			Reservation: token,
+			// the reservation expires soon, so the key of the request interrupted by a crash is released
+			ExpiresAt: time.Now().UTC().Add(ctx.Config(r).IdempotencyInProgressTTL()),
+		}
+		reserved, err := keys.Reserve(r.Context(), reservation)
+		if err != nil {
+			log.WithError(err).Error("failed to reserve idempotency key")
+			render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+			return
+		}
+
+		if !reserved {
+			stored, err := keys.Get(r.Context(), reservation.Key)
+			if err != nil {
+				log.WithError(err).Error("failed to get idempotency key")
+				render.Respond(w, http.StatusInternalServerError, render.Message("something bad happened"))
+				return
+			}
+			switch {
+			case stored != nil && stored.RequestHash != reservation.RequestHash:
+				render.Respond(w, http.StatusUnprocessableEntity, render.Message("idempotency key was already used for another request"))
+			case stored != nil && stored.Committed() && !stored.Completed():
+				// the changes are committed, but the response was lost, so the request must not be processed again
+				render.Respond(w, http.StatusConflict, render.Message("request with the same idempotency key was already processed"))
+			case stored == nil || !stored.Completed():
+				render.Respond(w, http.StatusConflict, render.Message("request with the same idempotency key is in progress"))
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(ReplayedHeader, "true")
+				w.WriteHeader(*stored.StatusCode)
+				_, _ = w.Write(stored.Response)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		// the committed key is never released, so the retry of the processed request is not processed again
+		release := func() {
+			err := keys.Release(r.Context(), reservation)
+			if errors.Is(err, postgres.ErrReservationLost) {
+				log.WithError(err).Warn("idempotency key was committed or taken over, not releasing it")
+				return
+			}
+			if err != nil {
+				log.WithError(err).Error("failed to release idempotency key")
+			}
+		}
+		defer func() {
+			if rvr := recover(); rvr != nil {
+				release()
+				panic(rvr)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), ctxReservation, reservation)))
+
+		if !completed(recorder.status) {
+			release()
+			return
+		}
+
+		expiresAt := time.Now().UTC().Add(ctx.Config(r).IdempotencyRetention())
+		if err := keys.Complete(r.Context(), reservation, recorder.status, recorder.body.Bytes(), expiresAt); err != nil {
+			log.WithError(err).Error("failed to store idempotent response")
+		}
+	})
+}
+
+// Commit marks the key of the request committed in the transaction of its changes, so the key is kept even if
+// the response is not stored. Returns postgres.ErrReservationLost if the reservation expired and may be taken over
+// by a retry, in which case the transaction must be rolled back. Does nothing for requests without the key.
+func Commit(r *http.Request, tx postgres.Storage) error {
+	reservation, ok := r.Context().Value(ctxReservation).(data.IdempotencyKey)
+	if !ok {
+		return nil
+	}
+
+	expiresAt := time.Now().UTC().Add(ctx.Config(r).IdempotencyRetention())
+	return tx.IdempotencyKeys().Commit(r.Context(), reservation, expiresAt)
+}
+
+// newReservation generates the random token identifying the request holding the key
+func newReservation() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", errors.Wrap(err, "failed to read random bytes")
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// completed checks whether the request ran to completion, so its response is stored. Rejections, e.g. by limits,
+// pauses or balances, and server errors are not stored, as nothing was done and the retry may succeed later
+func completed(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
+
+// hashRequest identifies the request by its method, path and body
+func hashRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte(r.URL.Path))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder writes the response through and keeps a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,214 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/data/memory"
+	"github.com/bsc-bridge-svc/internal/data/postgres"
+	"github.com/bsc-bridge-svc/internal/web/ctx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testConfig overrides only the settings used by the middleware
+type testConfig struct {
+	config.Config
+}
+
+func (testConfig) IdempotencyRetention() time.Duration {
+	return time.Hour
+}
+
+func (testConfig) IdempotencyInProgressTTL() time.Duration {
+	return time.Minute
+}
+
+// testHandler responds with the status and counts the requests it processed
+type testHandler struct {
+	status int
+	calls  int
+}
+
+func (h *testHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.calls++
+	w.WriteHeader(h.status)
+	_, _ = w.Write([]byte(`{}`))
+}
+
+// scopeBody scopes keys by the owner prefixing the body, e.g. "alice:1"
+func scopeBody(body []byte) string {
+	return strings.SplitN(string(body), ":", 2)[0]
+}
+
+func serve(storage postgres.Storage, handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	r := httptest.NewRequest(http.MethodPost, "/bsc/exchange", strings.NewReader(body))
+	r.Header.Set(KeyHeader, key)
+	w := httptest.NewRecorder()
+	ctx.Middleware(
+		ctx.CtxLog(log),
+		ctx.CtxConfig(testConfig{}),
+		ctx.CtxStorage(storage),
+	)(Middleware(scopeBody)(handler)).ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	storage := memory.NewStorage()
+	handler := &testHandler{status: http.StatusOK}
+
+	serve(storage, handler, "key", "alice:1")
+	w := serve(storage, handler, "key", "alice:1")
+	if handler.calls != 1 || w.Code != http.StatusOK || w.Header().Get(ReplayedHeader) != "true" {
+		t.Fatalf("expected response to be replayed, got %d after %d calls", w.Code, handler.calls)
+	}
+
+	// the key is reused by the same owner for another request
+	if w := serve(storage, handler, "key", "alice:2"); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", w.Code)
+	}
+
+	// keys of other owners do not collide
+	if w := serve(storage, handler, "key", "bob:1"); w.Code != http.StatusOK || handler.calls != 2 {
+		t.Fatalf("expected request of another owner to be processed, got %d after %d calls", w.Code, handler.calls)
+	}
+}
+
+func TestMiddleware_Rejected(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		storage := memory.NewStorage()
+		handler := &testHandler{status: status}
+
+		serve(storage, handler, "key", "alice:1")
+		handler.status = http.StatusOK
+		if w := serve(storage, handler, "key", "alice:1"); w.Code != http.StatusOK || handler.calls != 2 {
+			t.Fatalf("expected retry after %d to be processed, got %d after %d calls", status, w.Code, handler.calls)
+		}
+	}
+}
+
+func TestMiddleware_OrphanedReservation(t *testing.T) {
+	storage := memory.NewStorage()
+	handler := &testHandler{status: http.StatusOK}
+
+	// the instance processing the request crashed, so the key is neither completed nor released
+	hash := func(body string) string {
+		return hashRequest(httptest.NewRequest(http.MethodPost, "/bsc/exchange", nil), []byte(body))
+	}
+	_, err := storage.IdempotencyKeys().Reserve(context.Background(), data.IdempotencyKey{
+		Key:         "alice/key",
+		RequestHash: hash("alice:1"),
+		Reservation: "crashed",
+		ExpiresAt:   time.Now().UTC().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("failed to reserve key: %s", err)
+	}
+	if w := serve(storage, handler, "key", "alice:1"); w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 while the key is reserved, got %d", w.Code)
+	}
+
+	_, err = storage.IdempotencyKeys().Reserve(context.Background(), data.IdempotencyKey{
+		Key:         "bob/key",
+		RequestHash: hash("bob:1"),
+		Reservation: "crashed",
+		ExpiresAt:   time.Now().UTC().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("failed to reserve key: %s", err)
+	}
+	if w := serve(storage, handler, "key", "bob:1"); w.Code != http.StatusOK || handler.calls != 1 {
+		t.Fatalf("expected expired reservation to be taken over, got %d", w.Code)
+	}
+}
+
+// committingHandler commits the key with its changes and crashes before the response is stored
+type committingHandler struct {
+	calls int
+}
+
+func (h *committingHandler) ServeHTTP(_ http.ResponseWriter, r *http.Request) {
+	h.calls++
+	err := ctx.Storage(r).Transaction(r.Context(), func(tx postgres.Storage) error {
+		return Commit(r, tx)
+	})
+	if err != nil {
+		panic(err)
+	}
+	panic("crashed after commit")
+}
+
+func TestMiddleware_Committed(t *testing.T) {
+	storage := memory.NewStorage()
+	handler := &committingHandler{}
+
+	func() {
+		defer func() {
+			_ = recover()
+		}()
+		serve(storage, handler, "key", "alice:1")
+	}()
+
+	stored, err := storage.IdempotencyKeys().Get(context.Background(), "alice/key")
+	if err != nil || stored == nil || !stored.Committed() {
+		t.Fatalf("expected committed key to be kept, got %v, %v", stored, err)
+	}
+	if !stored.ExpiresAt.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("expected committed key to be kept for the retention, expires at %s", stored.ExpiresAt)
+	}
+	if w := serve(storage, handler, "key", "alice:1"); w.Code != http.StatusConflict || handler.calls != 1 {
+		t.Fatalf("expected retry of the committed request to be rejected, got %d after %d calls", w.Code, handler.calls)
+	}
+}
+
+func TestIdempotencyKeys_ReservationLost(t *testing.T) {
+	storage := memory.NewStorage()
+	keys := storage.IdempotencyKeys()
+	stale := data.IdempotencyKey{
+		Key:         "alice/key",
+		RequestHash: "hash",
+		Reservation: "stale",
+		ExpiresAt:   time.Now().UTC().Add(-time.Second),
+	}
+	current := stale
+	current.Reservation = "current"
+	current.ExpiresAt = time.Now().UTC().Add(time.Minute)
+
+	for _, key := range []data.IdempotencyKey{stale, current} {
+		if reserved, err := keys.Reserve(context.Background(), key); err != nil || !reserved {
+			t.Fatalf("failed to reserve key %s: %v", key.Reservation, err)
+		}
+	}
+
+	// the request which reservation expired does not affect the request which took the key over
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	if err := keys.Commit(context.Background(), stale, expiresAt); !errors.Is(err, postgres.ErrReservationLost) {
+		t.Fatalf("expected commit of the stale reservation to fail, got %v", err)
+	}
+	if err := keys.Complete(context.Background(), stale, http.StatusOK, []byte(`{}`), expiresAt); !errors.Is(err, postgres.ErrReservationLost) {
+		t.Fatalf("expected completion of the stale reservation to fail, got %v", err)
+	}
+	if err := keys.Release(context.Background(), stale); !errors.Is(err, postgres.ErrReservationLost) {
+		t.Fatalf("expected release of the stale reservation to fail, got %v", err)
+	}
+
+	stored, err := keys.Get(context.Background(), "alice/key")
+	if err != nil || stored == nil || stored.Completed() || stored.Committed() {
+		t.Fatalf("expected key of the current reservation to be untouched, got %v, %v", stored, err)
+	}
+	if err := keys.Commit(context.Background(), current, expiresAt); err != nil {
+		t.Fatalf("failed to commit current reservation: %s", err)
+	}
+	if err := keys.Release(context.Background(), current); !errors.Is(err, postgres.ErrReservationLost) {
+		t.Fatalf("expected committed key not to be released, got %v", err)
+	}
+}
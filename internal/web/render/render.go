@@ -11,6 +11,11 @@ func Message(message interface{}) map[string]interface{} {
 	return map[string]interface{}{"message": message}
 }
 
+// Page , function to render json response message with the cursor of the next page
+func Page(message interface{}, nextCursor *int64) map[string]interface{} {
+	return map[string]interface{}{"message": message, "next_cursor": nextCursor}
+}
+
 // Respond Valid json respond rendering
 func Respond(w http.ResponseWriter, status int, data map[string]interface{}) {
 	w.WriteHeader(status)
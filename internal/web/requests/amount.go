@@ -0,0 +1,83 @@
+package requests
+
+import (
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/amount"
+	"github.com/bsc-bridge-svc/internal/config"
+	"math/big"
+)
+
+// codes of invalid amounts, so frontends can tell the user what to fix
+const (
+	CodeInvalidAmount   = "invalid_amount"
+	CodeNotPositive     = "not_positive"
+	CodeTooManyDecimals = "too_many_decimals"
+	CodeBelowMinClaim   = "below_min_claim"
+	CodeAboveMaxClaim   = "above_max_claim"
+	CodeBelowFee        = "below_fee"
+)
+
+// AmountError describes the rule the requested amount violates
+type AmountError struct {
+	Code string
+	// Limit is the violated bound in whole tokens, e.g. the maximum number of decimals or the minimum claim
+	Limit   *string
+	Message string
+}
+
+func (e AmountError) Error() string {
+	return e.Message
+}
+
+func (e AmountError) ToReturn() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   e.Code,
+		"field":  "amount",
+		"limit":  e.Limit,
+		"detail": e.Message,
+	}
+}
+
+// parseAmount converts the positive decimal amount of whole tokens to base units, amounts with more decimals
+// than the token has are rejected instead of being truncated
+func parseAmount(raw string, token config.BinanceToken) (*big.Int, *AmountError) {
+	value, err := amount.ParseDecimal(raw)
+	if err != nil {
+		return nil, &AmountError{Code: CodeInvalidAmount, Message: "amount must be a decimal number, e.g. 1.5"}
+	}
+	if value.Sign() <= 0 {
+		return nil, &AmountError{Code: CodeNotPositive, Message: "amount must be positive"}
+	}
+
+	base, dust := amount.Round(amount.Shift(value, token.Precision), amount.RoundDown)
+	if dust.Sign() != 0 {
+		precision := fmt.Sprint(token.Precision)
+		return nil, &AmountError{
+			Code:    CodeTooManyDecimals,
+			Limit:   &precision,
+			Message: fmt.Sprintf("amount must have at most %d decimals", token.Precision),
+		}
+	}
+	return base, nil
+}
+
+// checkClaim checks the amount against the claim sizes of all the scopes
+func checkClaim(base *big.Int, token config.BinanceToken, limits ...config.AddressLimit) *AmountError {
+	for _, limit := range limits {
+		if limit.MinClaim != nil && base.Cmp(limit.MinClaim) < 0 {
+			min := amount.Format(limit.MinClaim, token.Precision)
+			return &AmountError{Code: CodeBelowMinClaim, Limit: &min, Message: fmt.Sprintf("amount is below the minimum claim %s", min)}
+		}
+		if limit.MaxClaim != nil && base.Cmp(limit.MaxClaim) > 0 {
+			max := amount.Format(limit.MaxClaim, token.Precision)
+			return &AmountError{Code: CodeAboveMaxClaim, Limit: &max, Message: fmt.Sprintf("amount is above the maximum claim %s", max)}
+		}
+	}
+	return nil
+}
+
+// FeeError describes the amount which does not cover the fee in base units
+func FeeError(fee *big.Int, token config.BinanceToken) AmountError {
+	limit := amount.Format(fee, token.Precision)
+	return AmountError{Code: CodeBelowFee, Limit: &limit, Message: fmt.Sprintf("amount must exceed the fee %s", limit)}
+}
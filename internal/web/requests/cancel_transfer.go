@@ -0,0 +1,47 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/go-chi/chi"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+type CancelTransferRequest struct {
+	ID int64 `json:"-"`
+	// Signature is the personal_sign of CancelTransferMessage by the binance address of the user
+	Signature string `json:"signature"`
+}
+
+// CancelTransferMessage is the message the user signs to cancel the transfer
+func CancelTransferMessage(id int64) string {
+	return fmt.Sprintf("Cancel bsc-bridge transfer %d", id)
+}
+
+func (r CancelTransferRequest) Validate() error {
+	return validation.Errors{
+		"signature": validation.Validate(r.Signature, validation.Required),
+	}.Filter()
+}
+
+func NewCancelTransferRequest(r *http.Request) (*CancelTransferRequest, error) {
+	req := CancelTransferRequest{}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, web.IDRequestKey), 10, 64)
+	if err != nil {
+		return nil, validation.Errors{
+			"id": errors.New("id must be an integer"),
+		}
+	}
+	req.ID = id
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(err, "failed to decode request body")
+	}
+
+	return &req, req.Validate()
+}
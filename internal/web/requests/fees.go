@@ -0,0 +1,58 @@
+package requests
+
+import (
+	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/config"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"math/big"
+	"net/http"
+)
+
+type GetQuoteRequest struct {
+	Amount string
+	Denom  string
+}
+
+func (r GetQuoteRequest) Validate() error {
+	return validation.Errors{
+		"amount": validation.Validate(r.Amount, validation.Required),
+		"denom":  validation.Validate(r.Denom, validation.Required),
+	}.Filter()
+}
+
+// ParseAmount validates the amount against decimals of the token, returns it in base units of the token
+func (r GetQuoteRequest) ParseAmount(token config.BinanceToken) (*big.Int, *AmountError) {
+	return parseAmount(r.Amount, token)
+}
+
+func NewGetQuoteRequest(r *http.Request) (*GetQuoteRequest, error) {
+	query := r.URL.Query()
+	req := GetQuoteRequest{
+		Amount: query.Get("amount"),
+		Denom:  query.Get("denom"),
+	}
+	return &req, req.Validate()
+}
+
+type SweepFeesRequest struct {
+	Denom string `json:"denom"`
+	Memo  string `json:"memo"`
+}
+
+func (r SweepFeesRequest) Validate() error {
+	return validation.Errors{
+		"denom": validation.Validate(r.Denom, validation.Required),
+		"memo":  validation.Validate(r.Memo, validation.Required, validation.Length(1, 1024)),
+	}.Filter()
+}
+
+func NewSweepFeesRequest(r *http.Request) (*SweepFeesRequest, error) {
+	req := SweepFeesRequest{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(err, "failed to decode request body")
+	}
+
+	return &req, req.Validate()
+}
@@ -0,0 +1,175 @@
+package requests
+
+import (
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/web"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/go-chi/chi"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Owner filters transfers by the addresses of the user, public requests are scoped to a single user
+type Owner struct {
+	OdinAddress    string
+	BinanceAddress string
+}
+
+// Scoped checks whether the owner is set
+func (o Owner) Scoped() bool {
+	return o.OdinAddress != "" || o.BinanceAddress != ""
+}
+
+type GetTransfersRequest struct {
+	Owner
+	Statuses []data.Status
+	Denoms   []string
+	From     *time.Time
+	To       *time.Time
+	Page     data.PageParams
+}
+
+// NewGetTransfersRequest parses the request, the owner is required if scoped
+func NewGetTransfersRequest(r *http.Request, scoped bool) (*GetTransfersRequest, error) {
+	query := r.URL.Query()
+	errs := validation.Errors{}
+	req := GetTransfersRequest{
+		Owner:  ownerParams(query, scoped, errs),
+		Denoms: listParam(query, "denom"),
+	}
+
+	for _, status := range listParam(query, "status") {
+		if !data.Status(status).Valid() {
+			errs["status"] = errors.Errorf("unknown status: %s", status)
+			continue
+		}
+		req.Statuses = append(req.Statuses, data.Status(status))
+	}
+
+	var err error
+	if req.From, err = timeParam(query, "from"); err != nil {
+		errs["from"] = err
+	}
+	if req.To, err = timeParam(query, "to"); err != nil {
+		errs["to"] = err
+	}
+
+	req.Page = pageParams(query, errs)
+
+	return &req, errs.Filter()
+}
+
+type GetTransferRequest struct {
+	Owner
+	ID int64
+}
+
+// NewGetTransferRequest parses the request, the owner is required if scoped
+func NewGetTransferRequest(r *http.Request, scoped bool) (*GetTransferRequest, error) {
+	errs := validation.Errors{}
+	req := GetTransferRequest{
+		Owner: ownerParams(r.URL.Query(), scoped, errs),
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, web.IDRequestKey), 10, 64)
+	if err != nil {
+		errs["id"] = errors.New("id must be an integer")
+	}
+	req.ID = id
+
+	return &req, errs.Filter()
+}
+
+// ownerParams parses the addresses of the owner, errors are added to errs
+func ownerParams(query url.Values, required bool, errs validation.Errors) Owner {
+	owner := Owner{
+		OdinAddress:    query.Get("odin_address"),
+		BinanceAddress: query.Get("binance_address"),
+	}
+
+	if owner.BinanceAddress != "" {
+		if ethcommon.IsHexAddress(owner.BinanceAddress) {
+			owner.BinanceAddress = CanonicalAddress(owner.BinanceAddress)
+		} else {
+			errs["binance_address"] = errors.New("address is not hex allowed")
+		}
+	}
+	if required && !owner.Scoped() {
+		errs["binance_address"] = errors.New("binance_address or odin_address is required")
+	}
+
+	return owner
+}
+
+// pageParams parses keyset pagination parameters, errors are added to errs
+func pageParams(query url.Values, errs validation.Errors) data.PageParams {
+	page := data.PageParams{
+		Limit: defaultPageLimit,
+		Order: data.OrderDesc,
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			errs["cursor"] = errors.New("cursor must be an integer")
+		} else {
+			page.Cursor = &cursor
+		}
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || limit == 0 || limit > maxPageLimit {
+			errs["limit"] = errors.Errorf("limit must be an integer from 1 to %d", maxPageLimit)
+		} else {
+			page.Limit = limit
+		}
+	}
+
+	if raw := query.Get("order"); raw != "" {
+		order := data.Order(raw)
+		if order != data.OrderAsc && order != data.OrderDesc {
+			errs["order"] = errors.New("order must be asc or desc")
+		} else {
+			page.Order = order
+		}
+	}
+
+	return page
+}
+
+// listParam supports both repeated and comma separated query parameters
+func listParam(query url.Values, key string) []string {
+	var result []string
+	for _, value := range query[key] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+func timeParam(query url.Values, key string) (*time.Time, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, errors.New("time must be in RFC3339 format")
+	}
+	return &parsed, nil
+}
@@ -2,10 +2,13 @@ package requests
 
 import (
 	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/config"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/pkg/errors"
+	"math/big"
 	"net/http"
+	"strings"
 )
 
 type GetUserRequest struct {
@@ -24,6 +27,35 @@ func (r GetUserRequest) Validate() error {
 	}.Filter()
 }
 
+// ParseAmount validates the amount against decimals of the token and the claim sizes of the binance and odin addresses,
+// returns it in base units of the token
+func (r GetUserRequest) ParseAmount(token config.BinanceToken, binance, odin config.AddressLimit) (*big.Int, *AmountError) {
+	base, err := parseAmount(r.Amount, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClaim(base, token, binance, odin); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// CanonicalAddress is the lowercase 0x-prefixed form of the hex address, so the same holder
+// in any case and with or without the prefix is the same user
+func CanonicalAddress(address string) string {
+	return strings.ToLower(ethcommon.HexToAddress(address).Hex())
+}
+
+// IdempotencyScope scopes idempotency keys of claims by the binance address, invalid requests are not scoped,
+// as they are rejected anyway
+func IdempotencyScope(body []byte) string {
+	req := GetUserRequest{}
+	if err := json.Unmarshal(body, &req); err != nil || !ethcommon.IsHexAddress(req.BinanceAddress) {
+		return ""
+	}
+	return CanonicalAddress(req.BinanceAddress)
+}
+
 func NewGetUserRequest(r *http.Request) (*GetUserRequest, error) {
 	req := GetUserRequest{}
 
@@ -37,6 +69,7 @@ func NewGetUserRequest(r *http.Request) (*GetUserRequest, error) {
 			"address": errors.New("address is not hex allowed"),
 		}
 	}
+	req.BinanceAddress = CanonicalAddress(req.BinanceAddress)
 
 	return &req, req.Validate()
 }
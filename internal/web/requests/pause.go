@@ -0,0 +1,60 @@
+package requests
+
+import (
+	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/go-chi/chi"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type PauseRequest struct {
+	Target string `json:"-"`
+	Reason string `json:"reason"`
+}
+
+func (r PauseRequest) Validate() error {
+	return validation.Errors{
+		"target": validateTarget(r.Target),
+		"reason": validation.Validate(r.Reason, validation.Required, validation.Length(1, 1024)),
+	}.Filter()
+}
+
+func NewPauseRequest(r *http.Request) (*PauseRequest, error) {
+	req := PauseRequest{
+		Target: chi.URLParam(r, web.TargetRequestKey),
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(err, "failed to decode request body")
+	}
+
+	return &req, req.Validate()
+}
+
+type ResumeRequest struct {
+	Target string
+}
+
+func (r ResumeRequest) Validate() error {
+	return validation.Errors{
+		"target": validateTarget(r.Target),
+	}.Filter()
+}
+
+func NewResumeRequest(r *http.Request) (*ResumeRequest, error) {
+	req := ResumeRequest{
+		Target: chi.URLParam(r, web.TargetRequestKey),
+	}
+	return &req, req.Validate()
+}
+
+func validateTarget(target string) error {
+	targets := make([]interface{}, 0, len(data.PauseTargets))
+	for _, known := range data.PauseTargets {
+		targets = append(targets, known)
+	}
+	return validation.Validate(target, validation.Required, validation.In(targets...))
+}
@@ -0,0 +1,53 @@
+package requests
+
+import (
+	"encoding/json"
+	"github.com/bsc-bridge-svc/internal/data"
+	"github.com/bsc-bridge-svc/internal/web"
+	"github.com/go-chi/chi"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+type GetHeldTransfersRequest struct {
+	Page data.PageParams
+}
+
+func NewGetHeldTransfersRequest(r *http.Request) (*GetHeldTransfersRequest, error) {
+	errs := validation.Errors{}
+	req := GetHeldTransfersRequest{
+		Page: pageParams(r.URL.Query(), errs),
+	}
+	return &req, errs.Filter()
+}
+
+type ReviewTransferRequest struct {
+	ID     int64  `json:"-"`
+	Reason string `json:"reason"`
+}
+
+func (r ReviewTransferRequest) Validate() error {
+	return validation.Errors{
+		"reason": validation.Validate(r.Reason, validation.Required, validation.Length(1, 1024)),
+	}.Filter()
+}
+
+func NewReviewTransferRequest(r *http.Request) (*ReviewTransferRequest, error) {
+	req := ReviewTransferRequest{}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, web.IDRequestKey), 10, 64)
+	if err != nil {
+		return nil, validation.Errors{
+			"id": errors.New("id must be an integer"),
+		}
+	}
+	req.ID = id
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(err, "failed to decode request body")
+	}
+
+	return &req, req.Validate()
+}
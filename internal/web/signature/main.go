@@ -0,0 +1,38 @@
+package signature
+
+import (
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidSignature = errors.New("signature is invalid")
+
+// Verify checks that the message is signed by the BSC address with personal_sign (EIP-191),
+// as wallets like MetaMask do
+func Verify(address ethcommon.Address, message, signature string) error {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, "signature is not hex encoded")
+	}
+	if len(sig) != crypto.SignatureLength {
+		return errors.Wrapf(ErrInvalidSignature, "signature must be %d bytes long", crypto.SignatureLength)
+	}
+
+	// wallets return the recovery id as 27 or 28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, err.Error())
+	}
+
+	if crypto.PubkeyToAddress(*pubKey) != address {
+		return errors.Wrap(ErrInvalidSignature, "message is signed by another address")
+	}
+	return nil
+}
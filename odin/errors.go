@@ -0,0 +1,63 @@
+package odin
+
+import (
+	odinmint "github.com/GeoDB-Limited/odin-core/x/mint/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrOutcomeUnknown means the transaction may be included, so it must be neither retried nor refunded
+// until the chain proves it is not included
+var ErrOutcomeUnknown = errors.New("outcome of the transaction is unknown")
+
+// transientErrors may disappear on their own, so the withdrawal is worth retrying
+var transientErrors = []error{
+	sdkerrors.ErrWrongSequence,
+	sdkerrors.ErrOutOfGas,
+	sdkerrors.ErrInsufficientFee,
+	sdkerrors.ErrInsufficientFunds,
+	sdkerrors.ErrMempoolIsFull,
+	odinmint.ErrWithdrawalAmountExceedsModuleBalance,
+	odinmint.ErrExceedsWithdrawalLimitPerTime,
+}
+
+// transientCodes are grpc codes of the connection problems
+var transientCodes = []codes.Code{
+	codes.Unavailable,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Aborted,
+}
+
+// TxError returns the error of the failed transaction, nil if it succeeded
+func TxError(resp *sdk.TxResponse) error {
+	if resp.Code == 0 {
+		return nil
+	}
+	return sdkerrors.ABCIError(resp.Codespace, resp.Code, resp.RawLog)
+}
+
+// IsTransient checks whether the failed withdrawal may succeed if it is retried later,
+// any unknown error is considered permanent, e.g. invalid address or unknown denom
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, transient := range transientErrors {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+
+	code := status.Code(errors.Cause(err))
+	for _, transient := range transientCodes {
+		if code == transient {
+			return true
+		}
+	}
+	return false
+}
@@ -2,19 +2,27 @@ package odin
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	odinapp "github.com/GeoDB-Limited/odin-core/app"
 	odincoinswap "github.com/GeoDB-Limited/odin-core/x/coinswap/types"
 	odinmint "github.com/GeoDB-Limited/odin-core/x/mint/types"
 	"github.com/bsc-bridge-svc/internal/config"
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
 	sdktxclient "github.com/cosmos/cosmos-sdk/client/tx"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 	"github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	sdkauthsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
 	sdkauth "github.com/cosmos/cosmos-sdk/x/auth/types"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
 )
 
 var (
@@ -25,10 +33,35 @@ var (
 type Client interface {
 	WithSigner() Client
 	GetAccount(string) (sdkauth.AccountI, error)
-	ClaimWithdrawal(string, sdk.Coin) error
+	SignWithdrawal(string, sdk.Coin) (*Withdrawal, error)
+	// Discard releases the sequence of the signed withdrawal, which is not going to be broadcast
+	Discard(*Withdrawal)
+	Broadcast(*Withdrawal) (*sdk.TxResponse, error)
+	GetTx(hash string) (*sdk.TxResponse, error)
+	GetLatestHeight() (int64, error)
 	GetExchangeRate(string) (sdk.Dec, error)
 }
 
+// Withdrawal is the signed transaction to claim withdrawal from Odin,
+// it is not included in blocks after the timeout height
+type Withdrawal struct {
+	TxHash        string
+	TimeoutHeight int64
+	txBytes       []byte
+	sequence      uint64
+	// generation is the sync of the signer the sequence was allocated in
+	generation uint64
+}
+
+// NewWithdrawal creates the withdrawal of the already signed transaction
+func NewWithdrawal(txBytes []byte, timeoutHeight int64) *Withdrawal {
+	return &Withdrawal{
+		TxHash:        fmt.Sprintf("%X", sha256.Sum256(txBytes)),
+		TimeoutHeight: timeoutHeight,
+		txBytes:       txBytes,
+	}
+}
+
 // client defines typed wrapper for the cosmos sdk service client.
 type client struct {
 	connection *grpc.ClientConn
@@ -41,6 +74,15 @@ type client struct {
 type signer struct {
 	address    sdk.AccAddress
 	privateKey *secp256k1.PrivKey
+
+	// signed transactions are broadcast concurrently, so the sequence is allocated locally
+	// and is taken from the chain only once it is not synced
+	mu            sync.Mutex
+	synced        bool
+	accountNumber uint64
+	sequence      uint64
+	// generation is incremented on every sync, so the stale sequence mismatch does not reset the synced one
+	generation uint64
 }
 
 // New creates a client that uses the given cosmos sdk service client.
@@ -74,42 +116,144 @@ func (c *client) WithSigner() Client {
 	}
 }
 
-// ClaimWithdrawal claims withdrawing from Odin
-func (c *client) ClaimWithdrawal(address string, amount sdk.Coin) error {
+// SignWithdrawal signs the transaction to claim withdrawing from Odin, so its hash is known before broadcasting
+func (c *client) SignWithdrawal(address string, amount sdk.Coin) (*Withdrawal, error) {
 	receiverAddress, err := sdk.AccAddressFromBech32(address)
 	if err != nil {
-		return errors.Wrapf(err, "failed to parse receiver address: %s", address)
+		return nil, sdkerrors.Wrapf(sdkerrors.ErrInvalidAddress, "failed to parse receiver address %s: %s", address, err)
 	}
 
+	height, err := c.GetLatestHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get timeout height")
+	}
+	timeoutHeight := height + c.cfg.OdinTimeoutBlocks()
+
 	msg := odinmint.NewMsgWithdrawCoinsToAccFromTreasury(sdk.NewCoins(amount), receiverAddress, c.signer.address)
-	txBytes, err := c.signTx(&msg)
+	accountNumber, sequence, generation, err := c.nextSequence()
 	if err != nil {
-		return errors.Wrapf(err, "failed to sign the transaction to to claim withdrawal with message: %s", msg.String())
+		return nil, err
+	}
+
+	txBytes, err := c.signTx(&msg, timeoutHeight, accountNumber, sequence)
+	if err != nil {
+		// the transaction is not broadcast, so its sequence is released like of the discarded one
+		c.Discard(&Withdrawal{sequence: sequence, generation: generation})
+		return nil, errors.Wrapf(err, "failed to sign the transaction to to claim withdrawal with message: %s", msg.String())
+	}
+
+	withdrawal := NewWithdrawal(txBytes, timeoutHeight)
+	withdrawal.sequence = sequence
+	withdrawal.generation = generation
+	return withdrawal, nil
+}
+
+// nextSequence allocates the sequence of the next transaction of the signer, the signer is synced with the chain
+// under the lock, so concurrent transactions wait for the sync instead of fetching the sequence each
+func (c *client) nextSequence() (accountNumber, sequence, generation uint64, err error) {
+	c.signer.mu.Lock()
+	defer c.signer.mu.Unlock()
+
+	if !c.signer.synced {
+		account, err := c.GetAccount(c.signer.address.String())
+		if err != nil {
+			return 0, 0, 0, errors.Wrapf(err, "failed to get account of signer: %s", c.signer.address.String())
+		}
+		c.signer.accountNumber = account.GetAccountNumber()
+		c.signer.sequence = account.GetSequence()
+		c.signer.generation++
+		c.signer.synced = true
+	}
+
+	sequence = c.signer.sequence
+	c.signer.sequence++
+	return c.signer.accountNumber, sequence, c.signer.generation, nil
+}
+
+// resetSequence makes the next transaction take the sequence from the chain, once per sync: the withdrawals
+// signed before the sync are rejected for the sequence as well, but the sync already accounts for them
+func (c *client) resetSequence(withdrawal *Withdrawal) {
+	c.signer.mu.Lock()
+	defer c.signer.mu.Unlock()
+
+	if c.signer.generation == withdrawal.generation {
+		c.signer.synced = false
 	}
+}
+
+func (c *client) Discard(withdrawal *Withdrawal) {
+	c.signer.mu.Lock()
+	defer c.signer.mu.Unlock()
 
+	if !c.signer.synced || c.signer.generation != withdrawal.generation {
+		return
+	}
+	// the sequence is reused only if no other transaction is signed after the discarded one
+	if c.signer.sequence == withdrawal.sequence+1 {
+		c.signer.sequence--
+		return
+	}
+	c.signer.synced = false
+}
+
+// Broadcast broadcasts the signed withdrawal, returns ErrOutcomeUnknown if the transaction may be submitted,
+// but its result is not received
+func (c *client) Broadcast(withdrawal *Withdrawal) (*sdk.TxResponse, error) {
 	serviceClient := tx.NewServiceClient(c.connection)
 	resp, err := serviceClient.BroadcastTx(
 		c.context,
 		&tx.BroadcastTxRequest{
 			Mode:    tx.BroadcastMode_BROADCAST_MODE_BLOCK,
-			TxBytes: txBytes,
+			TxBytes: withdrawal.txBytes,
 		},
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to broadcast transaction")
+		// the transaction may be accepted, so the sequence is kept, the mismatch of the next one resyncs it otherwise
+		return nil, errors.Wrapf(ErrOutcomeUnknown, "failed to broadcast transaction %s: %s", withdrawal.TxHash, err)
 	}
 
-	if resp.TxResponse.Code != 0 {
-		return errors.Errorf("failed to withdraw coins from minting module: %s", resp.TxResponse.RawLog)
+	if err := TxError(resp.TxResponse); err != nil {
+		// only the wrong sequence is taken from the chain again, other failures keep the allocated ones
+		if errors.Is(err, sdkerrors.ErrWrongSequence) {
+			c.resetSequence(withdrawal)
+		}
+		return resp.TxResponse, errors.Wrap(err, "failed to withdraw coins from minting module")
 	}
 
-	return nil
+	return resp.TxResponse, nil
 }
 
-// signTx signs the transaction with the given message
-func (c *client) signTx(msg sdk.Msg) ([]byte, error) {
+// GetTx returns the response of the transaction included in a block, nil if it is not found
+func (c *client) GetTx(hash string) (*sdk.TxResponse, error) {
+	serviceClient := tx.NewServiceClient(c.connection)
+	resp, err := serviceClient.GetTx(c.context, &tx.GetTxRequest{Hash: hash})
+	if err != nil {
+		// the node returns the plain error if the transaction is not indexed
+		if status.Code(err) == codes.NotFound || strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to query transaction %s", hash)
+	}
+
+	return resp.TxResponse, nil
+}
+
+// GetLatestHeight returns the height of the latest block
+func (c *client) GetLatestHeight() (int64, error) {
+	serviceClient := tmservice.NewServiceClient(c.connection)
+	resp, err := serviceClient.GetLatestBlock(c.context, &tmservice.GetLatestBlockRequest{})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to query latest block")
+	}
+
+	return resp.Block.Header.Height, nil
+}
+
+// signTx signs the transaction with the given message by the account sequence
+func (c *client) signTx(msg sdk.Msg, timeoutHeight int64, accNumber, accSequence uint64) ([]byte, error) {
 	txBuilder := encoding.TxConfig.NewTxBuilder()
 	txBuilder.SetMemo(c.cfg.OdinMemo())
+	txBuilder.SetTimeoutHeight(uint64(timeoutHeight))
 	denom, _ := c.cfg.OdinExchange()
 	fee := sdk.NewCoins(sdk.NewCoin(denom, sdk.NewIntFromBigInt(c.cfg.OdinGasPrice())))
 	txBuilder.SetFeeAmount(fee)
@@ -119,13 +263,6 @@ func (c *client) signTx(msg sdk.Msg) ([]byte, error) {
 		return nil, errors.Wrapf(err, "failed to set transaction builder message: %s", msg.String())
 	}
 
-	account, err := c.GetAccount(c.signer.address.String())
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get account of signer: %s", c.signer.address.String())
-	}
-	accSequence := account.GetSequence()
-	accNumber := account.GetAccountNumber()
-
 	signV2 := signing.SignatureV2{
 		PubKey: c.signer.privateKey.PubKey(),
 		Data: &signing.SingleSignatureData{
@@ -144,7 +281,7 @@ func (c *client) signTx(msg sdk.Msg) ([]byte, error) {
 		Sequence:      accSequence,
 	}
 
-	signV2, err = sdktxclient.SignWithPrivKey(
+	signV2, err := sdktxclient.SignWithPrivKey(
 		encoding.TxConfig.SignModeHandler().DefaultMode(),
 		signerData,
 		txBuilder,
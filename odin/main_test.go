@@ -0,0 +1,66 @@
+package odin
+
+import "testing"
+
+// syncedClient returns the client which signer is synced at the sequence, so the chain is not queried
+func syncedClient(sequence uint64) *client {
+	return &client{
+		signer: &signer{
+			synced:     true,
+			sequence:   sequence,
+			generation: 1,
+		},
+	}
+}
+
+func allocate(t *testing.T, c *client) *Withdrawal {
+	_, sequence, generation, err := c.nextSequence()
+	if err != nil {
+		t.Fatalf("failed to allocate sequence: %s", err)
+	}
+	return &Withdrawal{sequence: sequence, generation: generation}
+}
+
+func TestResetSequence_Stale(t *testing.T) {
+	c := syncedClient(10)
+	first := allocate(t, c)
+	second := allocate(t, c)
+
+	// the first mismatch resyncs the signer
+	c.resetSequence(first)
+	if c.signer.synced {
+		t.Fatal("expected signer to be resynced on the sequence mismatch")
+	}
+
+	// the resync is simulated, as the chain is not available
+	c.signer.synced = true
+	c.signer.generation++
+	c.signer.sequence = 11
+	third := allocate(t, c)
+
+	// the withdrawal signed before the resync fails with the mismatch as well, but does not reset the synced sequence
+	c.resetSequence(second)
+	c.Discard(second)
+	if !c.signer.synced || c.signer.sequence != third.sequence+1 {
+		t.Fatalf("expected synced sequence to be kept, got %+v", c.signer)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	c := syncedClient(10)
+	first := allocate(t, c)
+	second := allocate(t, c)
+
+	// the last sequence is reused
+	c.Discard(second)
+	if !c.signer.synced || c.signer.sequence != second.sequence {
+		t.Fatalf("expected sequence %d to be reused, got %+v", second.sequence, c.signer)
+	}
+
+	// the sequence followed by another one leaves the gap, so the signer is resynced
+	allocate(t, c)
+	c.Discard(first)
+	if c.signer.synced {
+		t.Fatal("expected signer to be resynced after the gap")
+	}
+}